@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/infra/audit"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/infra/database/redis"
+)
+
+// newAuditRecorder builds the audit sink chosen by AUDIT_SINK (stdout, file or
+// redis), sampled when AUDIT_SAMPLE_PER_SECOND is positive. It returns a nil
+// recorder when auditing is disabled and, for the file and redis sinks, what to
+// close on shutdown.
+func newAuditRecorder(store *redis.RedisStore, logger *slog.Logger) (verify.AuditRecorder, io.Closer, error) {
+	var recorder verify.AuditRecorder
+	var closer io.Closer
+
+	switch sink := os.Getenv("AUDIT_SINK"); sink {
+	case "":
+		return nil, nil, nil
+	case "stdout":
+		recorder = audit.NewJSONWriter(os.Stdout, logger)
+	case "file":
+		path := envOr("AUDIT_FILE_PATH", "audit.log")
+		maxMB, err := envInt("AUDIT_FILE_MAX_MB", 100)
+		if err != nil {
+			return nil, nil, err
+		}
+		maxBackups, err := envInt("AUDIT_FILE_MAX_BACKUPS", 5)
+		if err != nil {
+			return nil, nil, err
+		}
+		file, err := audit.NewRotatingFile(path, int64(maxMB)<<20, maxBackups)
+		if err != nil {
+			return nil, nil, err
+		}
+		recorder, closer = audit.NewJSONWriter(file, logger), file
+	case "redis":
+		maxLen, err := envInt("AUDIT_REDIS_MAXLEN", 100000)
+		if err != nil {
+			return nil, nil, err
+		}
+		buffer, err := envInt("AUDIT_REDIS_BUFFER", 10000)
+		if err != nil {
+			return nil, nil, err
+		}
+		stream := store.AuditStream(envOr("AUDIT_REDIS_STREAM", "rate_limit_audit"), int64(maxLen), buffer)
+		recorder, closer = stream, stream
+	default:
+		return nil, nil, fmt.Errorf("unknown AUDIT_SINK %q", sink)
+	}
+
+	perSecond, err := envInt("AUDIT_SAMPLE_PER_SECOND", 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	if perSecond > 0 {
+		recorder = audit.NewSampler(recorder, perSecond, time.Now)
+	}
+	return recorder, closer, nil
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func envInt(name string, fallback int) (int, error) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid value for %s: %q", name, raw)
+	}
+	return value, nil
+}
+
+func envDuration(name string, fallback time.Duration) (time.Duration, error) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid duration for %s: %q", name, raw)
+	}
+	return value, nil
+}
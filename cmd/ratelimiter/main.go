@@ -1,58 +1,169 @@
 package main
 
 import (
+	"expvar"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
-	"ratelim/internal/api/web/handlers"
-	"ratelim/internal/api/web/middleware/ratelimiter/configs"
-	"ratelim/internal/api/web/middleware/ratelimiter/usecase/verify"
-	"ratelim/internal/domain/mydomain/usecase"
-	"ratelim/internal/infra/database/redis"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/handlers"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/adaptive"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/configs"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/localcounter"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/shedding"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usage"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/domain/mydomain/usecase"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/infra/database/redis"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/infra/logging"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/infra/metrics"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
 func main() {
-	router := NewRouter()
-	router.Run(":8080")
+	// Subcomandos de linha de comando, ex.: ratelimiter usage export
+	if len(os.Args) > 1 && os.Args[1] == "usage" {
+		os.Exit(runUsage(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	router, closers := NewRouter()
+
+	serverConfig, err := LoadServerConfig()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load server config: %v", err))
+	}
+
+	srv := NewServer(serverConfig, router)
+	slog.Info("servidor escutando", "addr", serverConfig.Addr)
+	if err := Serve(serverConfig, srv, closers...); err != nil {
+		panic(err)
+	}
 }
 
-// NewRouter returns a configured gin.Engine
-func NewRouter() *gin.Engine {
+// NewRouter returns a configured gin.Engine and the resources to close on
+// shutdown, in the order they must be closed
+func NewRouter() (*gin.Engine, []io.Closer) {
 	// Load env
 	_ = godotenv.Load("cmd/ratelimiter/.env")
 
+	// Setup logging; packages without an injected logger use the default one
+	logger, err := logging.New(os.Stderr, os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT"))
+	if err != nil {
+		panic(fmt.Sprintf("Failed to setup logging: %v", err))
+	}
+	slog.SetDefault(logger)
+
 	// Load config
 	rateLimConfiPath := os.Getenv("RATE_LIMIT_CONFIG_PATH")
-	fmt.Printf("Loading config from: %s\n", rateLimConfiPath)
+	logger.Info("carregando configuração", "path", rateLimConfiPath)
 	config, err := configs.LoadConfig(rateLimConfiPath)
 	if err != nil || len(config.Services) == 0 {
 		panic("Failed to load services config")
 	}
 
 	// Setup Redis
-	redisAddr := os.Getenv("REDIS_ADDR")
-	redisPassword := os.Getenv("REDIS_PASSWORD")
-	redisDB, _ := strconv.Atoi(os.Getenv("REDIS_DB"))
-	redisRepo := redis.NewRedisStore(redisAddr, redisPassword, redisDB)
+	configCacheTTL, err := envDuration("CONFIG_CACHE_TTL", 0)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load config cache TTL: %v", err))
+	}
+	redisRepo := newRedisStore(configCacheTTL, logger)
 
 	for _, service := range config.Services {
 		redisRepo.SetServiceConfig(*service)
 	}
 
-	// Setup handlers
-	usecase := usecase.NewMydomainUsecase()
-	helloService := handlers.NewHelloService(usecase)
+	localCounter := localcounter.NewCounter(redisRepo, time.Now, logger)
+	localCounter.Start(10 * time.Millisecond)
+
+	var shedder *shedding.Controller
+	if config.Shedding.Enabled() {
+		shedder = shedding.NewController(config.Shedding, time.Now)
+	}
+
+	adaptiveLimits := adaptive.NewController(time.Now)
+
+	usageAggregator := usage.NewAggregator(redisRepo, logger)
+	usageAggregator.Start(usageFlushInterval)
+
+	auditRecorder, auditCloser, err := newAuditRecorder(redisRepo, logger)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to setup audit log: %v", err))
+	}
 
-	ratelimiterUseCase := verify.NewVerifyUsecase(redisRepo)
+	ratelimiterUseCase := verify.NewVerifyUsecase(redisRepo, localCounter, metrics.NewShadowRecorder(logger), shedder, adaptiveLimits, usageAggregator, auditRecorder, logger)
 	rateLimiter := handlers.NewRateLimiter(ratelimiterUseCase)
 
 	// Build router
 	router := gin.New()
-	router.Use(rateLimiter.Verify())
-	router.GET("/hello", helloService.Hello)
 
-	return router
+	// X-Forwarded-For and X-Real-IP are only honoured from these proxies;
+	// without any, the limited IP is always the connection's
+	var trustedProxies []string
+	if raw := os.Getenv("TRUSTED_PROXIES"); raw != "" {
+		for _, proxy := range strings.Split(raw, ",") {
+			trustedProxies = append(trustedProxies, strings.TrimSpace(proxy))
+		}
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		panic(fmt.Sprintf("Failed to setup trusted proxies: %v", err))
+	}
+
+	// Decision endpoint for edge proxies: it runs the usecase itself, so it is
+	// registered outside the rate limited routes
+	decision, err := handlers.NewDecision(ratelimiterUseCase, trustedProxies)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to setup decision endpoint: %v", err))
+	}
+	router.Any("/ratelimit/decision", decision.Check)
+	router.Any("/ratelimit/decision/*path", decision.Check)
+
+	// Self-service usage for API consumers; it only reads the key's counters
+	// and is limited by the caller's IP so it cannot be used to probe keys
+	status := handlers.NewStatus(ratelimiterUseCase)
+	router.GET("/ratelimit/status", rateLimiter.VerifyClientIP(), status.Get)
+
+	// Admin API, only enabled when a token is configured
+	if adminToken := os.Getenv("ADMIN_TOKEN"); adminToken != "" {
+		admin := handlers.NewAdmin(adaptiveLimits)
+		adminRoutes := router.Group("/admin", handlers.AdminAuth(adminToken))
+		adminRoutes.GET("/limits", admin.Limits)
+		// Process metrics, e.g. the would-be blocks of shadow mode limits
+		adminRoutes.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+	}
+
+	if config.Proxy.Enabled {
+		// Proxy mode: every other route is forwarded to the configured upstreams
+		proxy, err := handlers.NewProxy(config.Proxy)
+		if err != nil {
+			panic(fmt.Sprintf("Failed to setup proxy: %v", err))
+		}
+		router.NoRoute(rateLimiter.Verify(), proxy.Forward)
+	} else {
+		usecase := usecase.NewMydomainUsecase()
+		helloService := handlers.NewHelloService(usecase)
+
+		limited := router.Group("/", rateLimiter.Verify())
+		limited.GET("/hello", helloService.Hello)
+	}
+
+	// Pending local counts and usage must reach Redis before the connection is closed
+	closers := []io.Closer{localCounter, usageAggregator}
+	if auditCloser != nil {
+		closers = append(closers, auditCloser)
+	}
+	return router, append(closers, redisRepo)
+}
+
+// newRedisStore connects to the Redis configured in the environment
+func newRedisStore(configCacheTTL time.Duration, logger *slog.Logger) *redis.RedisStore {
+	redisAddr := os.Getenv("REDIS_ADDR")
+	redisPassword := os.Getenv("REDIS_PASSWORD")
+	redisDB, _ := strconv.Atoi(os.Getenv("REDIS_DB"))
+	return redis.NewRedisStore(redisAddr, redisPassword, redisDB, configCacheTTL, logger)
 }
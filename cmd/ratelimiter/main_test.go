@@ -15,7 +15,7 @@ func TestMain(m *testing.M) {
 	os.Setenv("RATE_LIMIT_CONFIG_PATH", "../../configs/middleware/services.yaml")
 
 	go func() {
-		router := NewRouter()
+		router, _ := NewRouter()
 		err := router.Run(":8081")
 		if err != nil {
 			panic(err)
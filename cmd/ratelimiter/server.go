@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ServerConfig holds the HTTP server settings read from the environment
+type ServerConfig struct {
+	Addr            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+	TLSCertFile     string
+	TLSKeyFile      string
+}
+
+// TLSEnabled reports whether both certificate and key files were configured
+func (c ServerConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// LoadServerConfig reads the server settings from the environment, falling back to safe defaults
+func LoadServerConfig() (ServerConfig, error) {
+	cfg := ServerConfig{
+		Addr:        os.Getenv("HTTP_ADDR"),
+		TLSCertFile: os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:  os.Getenv("TLS_KEY_FILE"),
+	}
+	if cfg.Addr == "" {
+		cfg.Addr = ":8080"
+	}
+
+	durations := []struct {
+		env   string
+		dst   *time.Duration
+		value time.Duration
+	}{
+		{"HTTP_READ_TIMEOUT", &cfg.ReadTimeout, 10 * time.Second},
+		{"HTTP_WRITE_TIMEOUT", &cfg.WriteTimeout, 30 * time.Second},
+		{"HTTP_IDLE_TIMEOUT", &cfg.IdleTimeout, 120 * time.Second},
+		{"HTTP_SHUTDOWN_TIMEOUT", &cfg.ShutdownTimeout, 15 * time.Second},
+	}
+	for _, d := range durations {
+		*d.dst = d.value
+		raw := os.Getenv(d.env)
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			return cfg, fmt.Errorf("invalid duration for %s: %q", d.env, raw)
+		}
+		*d.dst = parsed
+	}
+
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return cfg, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	return cfg, nil
+}
+
+// NewServer builds an http.Server for the given handler using the configured timeouts
+func NewServer(cfg ServerConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      handler,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+}
+
+// Serve runs the server until SIGINT/SIGTERM is received, then drains in-flight
+// requests and closes the given resources in order
+func Serve(cfg ServerConfig, srv *http.Server, closers ...io.Closer) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return serve(ctx, cfg, srv, closers...)
+}
+
+// serve runs the server until ctx is done, then drains and closes like Serve
+func serve(ctx context.Context, cfg ServerConfig, srv *http.Server, closers ...io.Closer) error {
+	errCh := make(chan error, 1)
+	go func() {
+		var err error
+		if cfg.TLSEnabled() {
+			err = srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	select {
+	case err := <-errCh:
+		// Server stopped on its own before any signal
+		return errors.Join(err, closeAll(closers))
+	case <-ctx.Done():
+	}
+
+	// Drain in-flight requests
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	shutdownErr := srv.Shutdown(shutdownCtx)
+
+	// Release Redis connections only after the handlers are done with them
+	return errors.Join(shutdownErr, closeAll(closers))
+}
+
+func closeAll(closers []io.Closer) error {
+	var errs []error
+	for _, c := range closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadServerConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    ServerConfig
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			want: ServerConfig{
+				Addr:            ":8080",
+				ReadTimeout:     10 * time.Second,
+				WriteTimeout:    30 * time.Second,
+				IdleTimeout:     120 * time.Second,
+				ShutdownTimeout: 15 * time.Second,
+			},
+		},
+		{
+			name: "overrides",
+			env: map[string]string{
+				"HTTP_ADDR":             ":9090",
+				"HTTP_READ_TIMEOUT":     "1s",
+				"HTTP_SHUTDOWN_TIMEOUT": "0s",
+				"TLS_CERT_FILE":         "cert.pem",
+				"TLS_KEY_FILE":          "key.pem",
+			},
+			want: ServerConfig{
+				Addr:         ":9090",
+				ReadTimeout:  time.Second,
+				WriteTimeout: 30 * time.Second,
+				IdleTimeout:  120 * time.Second,
+				TLSCertFile:  "cert.pem",
+				TLSKeyFile:   "key.pem",
+			},
+		},
+		{
+			name:    "invalid duration",
+			env:     map[string]string{"HTTP_WRITE_TIMEOUT": "soon"},
+			wantErr: `invalid duration for HTTP_WRITE_TIMEOUT: "soon"`,
+		},
+		{
+			name:    "negative duration",
+			env:     map[string]string{"HTTP_IDLE_TIMEOUT": "-1s"},
+			wantErr: `invalid duration for HTTP_IDLE_TIMEOUT: "-1s"`,
+		},
+		{
+			name:    "certificate without key",
+			env:     map[string]string{"TLS_CERT_FILE": "cert.pem"},
+			wantErr: "TLS_CERT_FILE and TLS_KEY_FILE must be set together",
+		},
+		{
+			name:    "key without certificate",
+			env:     map[string]string{"TLS_KEY_FILE": "key.pem"},
+			wantErr: "TLS_CERT_FILE and TLS_KEY_FILE must be set together",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			for _, env := range []string{"HTTP_ADDR", "HTTP_READ_TIMEOUT", "HTTP_WRITE_TIMEOUT", "HTTP_IDLE_TIMEOUT", "HTTP_SHUTDOWN_TIMEOUT", "TLS_CERT_FILE", "TLS_KEY_FILE"} {
+				t.Setenv(env, tt.env[env])
+			}
+
+			// Act
+			cfg, err := LoadServerConfig()
+
+			// Assert
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, cfg)
+		})
+	}
+}
+
+func TestEnvDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    time.Duration
+		wantErr string
+	}{
+		{name: "unset", want: time.Minute},
+		{name: "set", raw: "30s", want: 30 * time.Second},
+		{name: "disabled", raw: "0"},
+		{name: "invalid", raw: "30", wantErr: `invalid duration for CONFIG_CACHE_TTL: "30"`},
+		{name: "negative", raw: "-1s", wantErr: `invalid duration for CONFIG_CACHE_TTL: "-1s"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			t.Setenv("CONFIG_CACHE_TTL", tt.raw)
+
+			// Act
+			got, err := envDuration("CONFIG_CACHE_TTL", time.Minute)
+
+			// Assert
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// closerFunc records the order resources were closed in
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+func TestServe_DrainsRequestsThenClosesInOrder(t *testing.T) {
+	// Arrange
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	var events []string
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		events = append(events, "request done")
+	})
+	cfg := ServerConfig{Addr: addr, ShutdownTimeout: time.Second}
+	srv := NewServer(cfg, handler)
+	closer := func(name string) closerFunc {
+		return func() error {
+			events = append(events, name)
+			return nil
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(ctx, cfg, srv, closer("local counter"), closer("redis"))
+	}()
+
+	responded := make(chan int, 1)
+	go func() {
+		var resp *http.Response
+		var err error
+		// Aguarda o servidor começar a escutar
+		for i := 0; i < 50; i++ {
+			if resp, err = http.Get("http://" + addr); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if err != nil {
+			responded <- 0
+			return
+		}
+		resp.Body.Close()
+		responded <- resp.StatusCode
+	}()
+
+	// Act
+	<-started
+	cancel()
+
+	// Assert
+	assert.NoError(t, <-done)
+	assert.Equal(t, http.StatusOK, <-responded)
+	assert.Equal(t, []string{"request done", "local counter", "redis"}, events)
+}
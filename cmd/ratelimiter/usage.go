@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/repository"
+
+	"github.com/joho/godotenv"
+)
+
+// usageFlushInterval is how often each instance adds its aggregated usage to Redis
+const usageFlushInterval = 10 * time.Second
+
+const usageHelp = "uso: ratelimiter usage export --from <data> [--to <data>] [--format csv|jsonl]"
+
+// usageTimeLayouts are the accepted formats of --from and --to, read as UTC
+// unless they carry an offset
+var usageTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02T15", "2006-01-02"}
+
+// runUsage runs the usage subcommands and returns the process exit code
+func runUsage(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "export" {
+		fmt.Fprintln(stderr, usageHelp)
+		return 2
+	}
+
+	opts, err := parseUsageExport(args[1:], stderr)
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(stderr, "erro: %v\n%s\n", err, usageHelp)
+		}
+		return 2
+	}
+
+	_ = godotenv.Load("cmd/ratelimiter/.env")
+	store := newRedisStore(0, nil)
+	defer store.Close()
+
+	if err := exportUsage(store, stdout, opts); err != nil {
+		fmt.Fprintf(stderr, "erro ao exportar uso: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// usageExportOptions selects the hours in [From, To) and the output format
+type usageExportOptions struct {
+	From   time.Time
+	To     time.Time
+	Format string
+}
+
+func parseUsageExport(args []string, stderr io.Writer) (usageExportOptions, error) {
+	var opts usageExportOptions
+	fs := flag.NewFlagSet("usage export", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	from := fs.String("from", "", "início do período, inclusivo (ex.: 2025-06-01 ou 2025-06-01T12:00:00Z)")
+	to := fs.String("to", "", "fim do período, exclusivo (padrão: agora)")
+	fs.StringVar(&opts.Format, "format", "csv", "formato de saída: csv ou jsonl")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+
+	if *from == "" {
+		return opts, errors.New("--from é obrigatório")
+	}
+	var err error
+	if opts.From, err = parseUsageTime(*from); err != nil {
+		return opts, fmt.Errorf("--from inválido: %w", err)
+	}
+	opts.To = time.Now()
+	if *to != "" {
+		if opts.To, err = parseUsageTime(*to); err != nil {
+			return opts, fmt.Errorf("--to inválido: %w", err)
+		}
+	}
+	if !opts.From.Before(opts.To) {
+		return opts, errors.New("--from deve ser anterior a --to")
+	}
+	if opts.Format != "csv" && opts.Format != "jsonl" {
+		return opts, fmt.Errorf("formato desconhecido %q", opts.Format)
+	}
+	return opts, nil
+}
+
+func parseUsageTime(raw string) (time.Time, error) {
+	for _, layout := range usageTimeLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("data %q não reconhecida", raw)
+}
+
+// exportUsage writes one line per service and hour, ordered by hour
+func exportUsage(store repository.UsageStore, w io.Writer, opts usageExportOptions) error {
+	records, err := store.GetUsage(opts.From, opts.To)
+	if err != nil {
+		return err
+	}
+
+	if opts.Format == "jsonl" {
+		enc := json.NewEncoder(w)
+		for _, rec := range records {
+			if err := enc.Encode(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"hour", "service", "admitted", "rejected"})
+	for _, rec := range records {
+		_ = out.Write([]string{
+			rec.Hour.Format(time.RFC3339),
+			rec.Service,
+			strconv.FormatInt(rec.Admitted, 10),
+			strconv.FormatInt(rec.Rejected, 10),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
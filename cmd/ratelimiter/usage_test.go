@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUsageStore returns fixed records and remembers the requested period
+type fakeUsageStore struct {
+	records  []entity.UsageRecord
+	from, to time.Time
+}
+
+func (f *fakeUsageStore) AddUsage(records []entity.UsageRecord) error { return nil }
+
+func (f *fakeUsageStore) GetUsage(from, to time.Time) ([]entity.UsageRecord, error) {
+	f.from, f.to = from, to
+	return f.records, nil
+}
+
+func newUsageStore() *fakeUsageStore {
+	hour := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	return &fakeUsageStore{records: []entity.UsageRecord{
+		{Hour: hour, Service: "service-a", Admitted: 120, Rejected: 3},
+		{Hour: hour.Add(time.Hour), Service: "service-b", Admitted: 7},
+	}}
+}
+
+func TestUsageExport_WritesCSV(t *testing.T) {
+	// Arrange
+	store := newUsageStore()
+	opts, err := parseUsageExport([]string{"--from", "2025-06-01", "--to", "2025-06-02"}, io.Discard)
+	require.NoError(t, err)
+	var out bytes.Buffer
+
+	// Act
+	err = exportUsage(store, &out, opts)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), store.from)
+	assert.Equal(t, time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC), store.to)
+	assert.Equal(t, "hour,service,admitted,rejected\n"+
+		"2025-06-01T10:00:00Z,service-a,120,3\n"+
+		"2025-06-01T11:00:00Z,service-b,7,0\n", out.String())
+}
+
+func TestUsageExport_WritesJSONLines(t *testing.T) {
+	// Arrange
+	store := newUsageStore()
+	opts, err := parseUsageExport([]string{"--from", "2025-06-01T10:00:00Z", "--format", "jsonl"}, io.Discard)
+	require.NoError(t, err)
+	var out bytes.Buffer
+
+	// Act
+	err = exportUsage(store, &out, opts)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, `{"hour":"2025-06-01T10:00:00Z","service":"service-a","admitted":120,"rejected":3}`+"\n"+
+		`{"hour":"2025-06-01T11:00:00Z","service":"service-b","admitted":7,"rejected":0}`+"\n", out.String())
+}
+
+func TestUsageExport_RejectsInvalidFlags(t *testing.T) {
+	cases := [][]string{
+		{},
+		{"--from", "ontem"},
+		{"--from", "2025-06-02", "--to", "2025-06-01"},
+		{"--from", "2025-06-01", "--format", "xml"},
+	}
+
+	for _, args := range cases {
+		// Act
+		_, err := parseUsageExport(args, io.Discard)
+
+		// Assert
+		assert.Error(t, err, args)
+	}
+}
@@ -0,0 +1,204 @@
+package interceptors
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/infra/logging"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// APIKeyMetadata is the metadata key carrying the caller's token
+const APIKeyMetadata = "api-key"
+
+// LanguageMetadata picks the language of the status messages, like the
+// Accept-Language header of HTTP
+const LanguageMetadata = "accept-language"
+
+// RequestIDMetadata is the metadata key carrying the request ID; one is
+// generated for calls that arrive without it
+const RequestIDMetadata = "x-request-id"
+
+type requesterKey struct{}
+
+// RequesterFromContext returns the service name resolved by the interceptors
+func RequesterFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(requesterKey{}).(string)
+	return name
+}
+
+type RateLimiter struct {
+	usecase v.VerifyUsecaseInterface
+}
+
+func NewRateLimiter(usecase v.VerifyUsecaseInterface) *RateLimiter {
+	return &RateLimiter{usecase: usecase}
+}
+
+func (r *RateLimiter) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		ctx, done, err := r.verify(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		defer finish(done, &err)
+		return handler(ctx, req)
+	}
+}
+
+func (r *RateLimiter) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx, done, err := r.verify(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		defer finish(done, &err)
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// finish hands the handler's error to done once the call ends. A panic counts
+// as Internal, so the slot is released and the call settled, and is then
+// re-raised for the recovery interceptors.
+func finish(done func(error), err *error) {
+	if rec := recover(); rec != nil {
+		done(status.Error(codes.Internal, "panic"))
+		panic(rec)
+	}
+	done(*err)
+}
+
+// verify runs the usecase and returns the context enriched with the requester
+// and the func to call with the handler's error once the call ends, or the gRPC
+// status error the call must fail with
+func (r *RateLimiter) verify(ctx context.Context, fullMethod string) (context.Context, func(error), error) {
+	requestID := firstMetadata(ctx, RequestIDMetadata)
+	if requestID == "" {
+		requestID = logging.NewRequestID()
+	}
+	ctx = logging.WithAttrs(ctx, slog.String("request_id", requestID))
+
+	input := v.VerifyInputDTO{
+		ApiKey:   firstMetadata(ctx, APIKeyMetadata),
+		ClientIp: clientIPFromPeer(ctx),
+		Method:   "POST",
+		Path:     fullMethod,
+		Language: firstMetadata(ctx, LanguageMetadata),
+
+		TrackInFlight: true,
+	}
+
+	block := r.usecase.Verify(ctx, input)
+	if block.Blocked {
+		return ctx, nil, toStatus(block)
+	}
+
+	// Handlers e Settle registram logs com os atributos da chamada
+	ctx = logging.WithAttrs(ctx,
+		slog.String("requester", block.Name),
+		slog.String("key_fingerprint", entity.KeyFingerprint(block.Key)),
+		slog.String("decision", v.Decision(block)),
+	)
+
+	started := time.Now()
+	done := func(handlerErr error) {
+		_ = r.usecase.Settle(ctx, block, v.SettleInputDTO{
+			Status:  httpStatusFromError(handlerErr),
+			Latency: time.Since(started),
+		})
+		if block.Release != nil {
+			block.Release()
+		}
+	}
+	return context.WithValue(ctx, requesterKey{}, block.Name), done, nil
+}
+
+// httpStatusFromError classifies the handler's error so that Settle can refund
+// calls that failed on the server side
+func httpStatusFromError(err error) int {
+	switch status.Code(err) {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unimplemented:
+		return http.StatusInternalServerError
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// toStatus maps the HTTP status chosen by the usecase to a gRPC status
+func toStatus(block v.VerifyOutputDTO) error {
+	switch block.Status {
+	case http.StatusTooManyRequests:
+		return withRetryInfo(status.New(codes.ResourceExhausted, block.Message), block.ResetAt)
+	case http.StatusForbidden:
+		return status.Error(codes.PermissionDenied, block.Message)
+	case http.StatusServiceUnavailable:
+		// Carga descartada: o cliente pode tentar de novo após o intervalo
+		return withRetryInfo(status.New(codes.Unavailable, block.Message), block.ResetAt)
+	default:
+		return status.Error(codes.Unavailable, block.Message)
+	}
+}
+
+// withRetryInfo tells the client when it may retry
+func withRetryInfo(st *status.Status, resetAt time.Time) error {
+	retryDelay := time.Until(resetAt)
+	if retryDelay < 0 {
+		retryDelay = 0
+	}
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryDelay)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func clientIPFromPeer(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// wrappedStream overrides Context so stream handlers see the requester
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (w *wrappedStream) Context() context.Context {
+	return w.ctx
+}
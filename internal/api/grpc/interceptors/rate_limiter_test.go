@@ -0,0 +1,188 @@
+package interceptors_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/grpc/interceptors"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeUsecase answers with a fixed decision per API key and records the input
+type fakeUsecase struct {
+	v.VerifyUsecaseInterface
+	decisions map[string]v.VerifyOutputDTO
+	last      v.VerifyInputDTO
+	settled   []v.SettleInputDTO
+}
+
+func (f *fakeUsecase) Verify(ctx context.Context, input v.VerifyInputDTO) v.VerifyOutputDTO {
+	f.last = input
+	return f.decisions[input.ApiKey]
+}
+
+func (f *fakeUsecase) Settle(ctx context.Context, verified v.VerifyOutputDTO, result v.SettleInputDTO) error {
+	f.settled = append(f.settled, result)
+	return nil
+}
+
+// requesterHealth records the requester seen by the handler
+type requesterHealth struct {
+	*health.Server
+	requester string
+}
+
+func (h *requesterHealth) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	h.requester = interceptors.RequesterFromContext(ctx)
+	return h.Server.Check(ctx, req)
+}
+
+func (h *requesterHealth) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	h.requester = interceptors.RequesterFromContext(stream.Context())
+	return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+}
+
+func startServer(t *testing.T, usecase v.VerifyUsecaseInterface) (healthpb.HealthClient, *requesterHealth) {
+	listener := bufconn.Listen(1024 * 1024)
+	limiter := interceptors.NewRateLimiter(usecase)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(limiter.Unary()),
+		grpc.StreamInterceptor(limiter.Stream()),
+	)
+	svc := &requesterHealth{Server: health.NewServer()}
+	healthpb.RegisterHealthServer(server, svc)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return healthpb.NewHealthClient(conn), svc
+}
+
+func withAPIKey(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), interceptors.APIKeyMetadata, key)
+}
+
+func newFakeUsecase() *fakeUsecase {
+	return &fakeUsecase{decisions: map[string]v.VerifyOutputDTO{
+		"allowed":   {Name: "service-a", Status: http.StatusOK},
+		"limited":   {Name: "service-b", Blocked: true, Status: http.StatusTooManyRequests, Message: "rate limited", ResetAt: time.Now().Add(5 * time.Second)},
+		"forbidden": {Name: "service-c", Blocked: true, Status: http.StatusForbidden, Message: "blocked"},
+		"broken":    {Name: "service-d", Blocked: true, Status: http.StatusInternalServerError, Message: "store down"},
+	}}
+}
+
+func TestUnary_AllowsAndPassesRequester(t *testing.T) {
+	// Arrange
+	usecase := newFakeUsecase()
+	client, svc := startServer(t, usecase)
+
+	// Act
+	_, err := client.Check(withAPIKey("allowed"), &healthpb.HealthCheckRequest{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "service-a", svc.requester)
+	assert.Equal(t, "allowed", usecase.last.ApiKey)
+	assert.NotEmpty(t, usecase.last.ClientIp)
+	require.Len(t, usecase.settled, 1)
+	assert.Equal(t, http.StatusOK, usecase.settled[0].Status)
+}
+
+func TestUnary_MapsBlockedDecisionsToStatusCodes(t *testing.T) {
+	// Arrange
+	client, _ := startServer(t, newFakeUsecase())
+	cases := map[string]codes.Code{
+		"limited":   codes.ResourceExhausted,
+		"forbidden": codes.PermissionDenied,
+		"broken":    codes.Unavailable,
+	}
+
+	for key, expected := range cases {
+		// Act
+		_, err := client.Check(withAPIKey(key), &healthpb.HealthCheckRequest{})
+
+		// Assert
+		assert.Equal(t, expected, status.Code(err), key)
+	}
+}
+
+func TestUnary_ResourceExhaustedCarriesRetryInfo(t *testing.T) {
+	// Arrange
+	client, _ := startServer(t, newFakeUsecase())
+
+	// Act
+	_, err := client.Check(withAPIKey("limited"), &healthpb.HealthCheckRequest{})
+
+	// Assert
+	st := status.Convert(err)
+	require.Len(t, st.Details(), 1)
+	retryInfo, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	assert.Greater(t, retryInfo.RetryDelay.AsDuration(), time.Duration(0))
+	assert.Equal(t, "rate limited", st.Message())
+}
+
+func TestStream_AllowsAndBlocks(t *testing.T) {
+	// Arrange
+	client, svc := startServer(t, newFakeUsecase())
+
+	// Act
+	allowed, err := client.Watch(withAPIKey("allowed"), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, allowedErr := allowed.Recv()
+
+	limited, err := client.Watch(withAPIKey("limited"), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, limitedErr := limited.Recv()
+
+	// Assert
+	assert.NoError(t, allowedErr)
+	assert.Equal(t, "service-a", svc.requester)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(limitedErr))
+}
+
+func TestUnary_PanicReleasesSlotAndSettlesAsServerError(t *testing.T) {
+	// Arrange
+	released := 0
+	usecase := &fakeUsecase{decisions: map[string]v.VerifyOutputDTO{
+		"": {Name: "default", Status: http.StatusOK, Release: func() { released++ }},
+	}}
+	unary := interceptors.NewRateLimiter(usecase).Unary()
+	handler := func(ctx context.Context, req any) (any, error) { panic("boom") }
+
+	// Act
+	var recovered any
+	func() {
+		defer func() { recovered = recover() }()
+		_, _ = unary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/svc/Method"}, handler)
+	}()
+
+	// Assert
+	assert.Equal(t, "boom", recovered)
+	assert.Equal(t, 1, released)
+	require.Len(t, usecase.settled, 1)
+	assert.Equal(t, http.StatusInternalServerError, usecase.settled[0].Status)
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/adaptive"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Admin exposes the limiter's runtime state to operators
+type Admin struct {
+	adaptive *adaptive.Controller
+}
+
+func NewAdmin(adaptive *adaptive.Controller) *Admin {
+	return &Admin{adaptive: adaptive}
+}
+
+// Limits lists the current effective limit of every adaptive service
+func (a *Admin) Limits(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"adaptive": a.adaptive.Snapshot()})
+}
+
+// AdminAuth only lets through requests carrying "Authorization: Bearer <token>"
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "token de administração inválido"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,50 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/handlers"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/adaptive"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdmin_ListsEffectiveLimitsBehindToken(t *testing.T) {
+	// Arrange
+	controller := adaptive.NewController(time.Now)
+	controller.Limit("s3arch", entity.ServiceConfig{
+		Name:       "search",
+		AllowedRPS: 10,
+		Adaptive:   entity.AdaptiveConfig{Enabled: true, MinRPS: 1, MaxRPS: 20, TargetLatency: "100ms"},
+	})
+
+	router := gin.New()
+	admin := handlers.NewAdmin(controller)
+	router.GET("/admin/limits", handlers.AdminAuth("s3cret"), admin.Limits)
+
+	do := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/admin/limits", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Act
+	anonymous := do("")
+	wrong := do("guess")
+	authorized := do("s3cret")
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, anonymous.Code)
+	assert.Equal(t, http.StatusUnauthorized, wrong.Code)
+	assert.Equal(t, http.StatusOK, authorized.Code)
+	assert.JSONEq(t, `{"adaptive":[{"name":"search","key_fingerprint":"`+entity.KeyFingerprint("s3arch")+`","effective_rps":10,"min_rps":1,"max_rps":20}]}`, authorized.Body.String())
+}
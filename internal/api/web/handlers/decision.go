@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// HeaderDecisionStatus always carries the real decision, even when
+	// deny_status rewrites the response code for nginx
+	HeaderDecisionStatus = "X-Ratelimit-Status"
+	HeaderRequester      = entity.DefaultRequesterHeader
+)
+
+// Decision answers "may this request proceed?" for an edge proxy (Envoy
+// ext_authz in HTTP mode or nginx auth_request) without serving the request itself
+type Decision struct {
+	usecase v.VerifyUsecaseInterface
+	trusted []netip.Prefix
+}
+
+// NewDecision builds the endpoint. Forwarded headers (X-Real-IP, X-Original-*)
+// are only honoured on calls from trustedProxies, given as IPs or CIDRs; any
+// other caller is checked by its own address, method and path.
+func NewDecision(usecase v.VerifyUsecaseInterface, trustedProxies []string) (*Decision, error) {
+	trusted, err := ParseTrustedProxies(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	return &Decision{usecase: usecase, trusted: trusted}, nil
+}
+
+// Check reads the original request from the forwarded headers and replies with
+// 200, or with the rejection status and the rate limit headers to copy to the client.
+// nginx only accepts 2xx/401/403 from auth_request, so "?deny_status=403" maps
+// every rejection to 403 while HeaderDecisionStatus keeps the real status.
+func (d *Decision) Check(c *gin.Context) {
+	input := v.VerifyInputDTO{
+		ApiKey:   c.GetHeader("Api-Key"),
+		ClientIp: c.RemoteIP(),
+		Method:   c.Request.Method,
+		Path:     originalPath(c, false),
+		Language: c.GetHeader("Accept-Language"),
+	}
+	// Clientes comuns não podem escolher o IP ou a rota contabilizados
+	if d.fromTrustedProxy(c) {
+		input.ClientIp = originalClientIP(c)
+		input.Method = firstHeader(c, c.Request.Method, "X-Original-Method", "X-Forwarded-Method")
+		input.Path = originalPath(c, true)
+	}
+
+	block := d.usecase.Verify(requestContext(c), input)
+	setRateLimitHeaders(c, block)
+	c.Header(HeaderDecisionStatus, strconv.Itoa(block.Status))
+
+	if !block.Blocked {
+		c.Header(HeaderRequester, block.Name)
+		c.Status(http.StatusOK)
+		return
+	}
+
+	status := block.Status
+	if denyStatus, err := strconv.Atoi(c.Query("deny_status")); err == nil && denyStatus >= 400 {
+		status = denyStatus
+	}
+	abortBlocked(c, status, block, input.Path)
+}
+
+// originalPath prefers the URI forwarded by nginx or Traefik, when forwarded
+// headers are honoured; Envoy appends the original path to the configured
+// path_prefix, which gin captures in "path"
+func originalPath(c *gin.Context, forwarded bool) string {
+	if uri := firstHeader(c, "", "X-Original-URI", "X-Forwarded-Uri"); forwarded && uri != "" {
+		path, _, _ := strings.Cut(uri, "?")
+		return path
+	}
+	if path := c.Param("path"); path != "" {
+		return path
+	}
+	return "/"
+}
+
+func originalClientIP(c *gin.Context) string {
+	if ip := c.GetHeader("X-Real-IP"); ip != "" {
+		return ip
+	}
+	return c.ClientIP()
+}
+
+// fromTrustedProxy reports whether the call comes straight from a trusted proxy
+func (d *Decision) fromTrustedProxy(c *gin.Context) bool {
+	addr, err := netip.ParseAddr(c.RemoteIP())
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range d.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTrustedProxies parses a list of IPs and CIDRs, such as TRUSTED_PROXIES
+func ParseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if strings.Contains(proxy, "/") {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+func firstHeader(c *gin.Context, fallback string, names ...string) string {
+	for _, name := range names {
+		if value := c.GetHeader(name); value != "" {
+			return value
+		}
+	}
+	return fallback
+}
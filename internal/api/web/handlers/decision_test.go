@@ -0,0 +1,124 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/handlers"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeVerify returns a fixed decision and records the input it received
+type fakeVerify struct {
+	v.VerifyUsecaseInterface
+	output v.VerifyOutputDTO
+	last   v.VerifyInputDTO
+}
+
+func (f *fakeVerify) Verify(ctx context.Context, input v.VerifyInputDTO) v.VerifyOutputDTO {
+	f.last = input
+	return f.output
+}
+
+// newDecisionRouter trusts the address httptest requests come from
+func newDecisionRouter(usecase v.VerifyUsecaseInterface) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	decision, _ := handlers.NewDecision(usecase, []string{"192.0.2.0/24"})
+	router := gin.New()
+	router.Any("/ratelimit/decision", decision.Check)
+	router.Any("/ratelimit/decision/*path", decision.Check)
+	return router
+}
+
+func TestDecision_AllowsAndReadsForwardedHeaders(t *testing.T) {
+	// Arrange
+	usecase := &fakeVerify{output: v.VerifyOutputDTO{
+		Name: "service-a", Status: http.StatusOK, Limit: 20, Remaining: 19, ResetAt: time.Now().Add(time.Second),
+	}}
+	router := newDecisionRouter(usecase)
+	req := httptest.NewRequest(http.MethodGet, "/ratelimit/decision", nil)
+	req.Header.Set("Api-Key", "abcd1234")
+	req.Header.Set("X-Original-Method", "POST")
+	req.Header.Set("X-Original-URI", "/orders?page=2")
+	req.Header.Set("X-Real-IP", "203.0.113.7")
+	rec := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, v.VerifyInputDTO{ApiKey: "abcd1234", ClientIp: "203.0.113.7", Method: "POST", Path: "/orders"}, usecase.last)
+	assert.Equal(t, "service-a", rec.Header().Get(handlers.HeaderRequester))
+	assert.Equal(t, "20", rec.Header().Get(handlers.HeaderLimit))
+	assert.Equal(t, "19", rec.Header().Get(handlers.HeaderRemaining))
+}
+
+func TestDecision_RejectsWithRetryAfterAndEnvoyPath(t *testing.T) {
+	// Arrange
+	usecase := &fakeVerify{output: v.VerifyOutputDTO{
+		Name: "service-a", Blocked: true, Status: http.StatusTooManyRequests, Message: "limited",
+		Limit: 20, ResetAt: time.Now().Add(3 * time.Second),
+	}}
+	router := newDecisionRouter(usecase)
+	rec := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/ratelimit/decision/orders/1", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "/orders/1", usecase.last.Path)
+	assert.Equal(t, http.MethodDelete, usecase.last.Method)
+	assert.NotEmpty(t, rec.Header().Get(handlers.HeaderRetryAfter))
+	assert.Equal(t, "0", rec.Header().Get(handlers.HeaderRemaining))
+}
+
+func TestDecision_DenyStatusRewritesRejectionForNginx(t *testing.T) {
+	// Arrange
+	usecase := &fakeVerify{output: v.VerifyOutputDTO{Blocked: true, Status: http.StatusTooManyRequests}}
+	router := newDecisionRouter(usecase)
+	rec := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ratelimit/decision?deny_status=403", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "429", rec.Header().Get(handlers.HeaderDecisionStatus))
+	// The problem body reports the status actually written
+	assert.Contains(t, rec.Body.String(), `"status":403`)
+	assert.Contains(t, rec.Body.String(), `"title":"Forbidden"`)
+}
+
+func TestDecision_IgnoresForwardedHeadersFromUntrustedCallers(t *testing.T) {
+	// Arrange
+	usecase := &fakeVerify{output: v.VerifyOutputDTO{Name: "default", Status: http.StatusOK}}
+	gin.SetMode(gin.TestMode)
+	decision, err := handlers.NewDecision(usecase, []string{"10.0.0.1", "2001:db8::/32"})
+	router := gin.New()
+	router.Any("/ratelimit/decision", decision.Check)
+
+	req := httptest.NewRequest(http.MethodGet, "/ratelimit/decision", nil)
+	req.RemoteAddr = "198.51.100.9:4321"
+	req.Header.Set("X-Real-IP", "203.0.113.7")
+	req.Header.Set("X-Original-Method", "POST")
+	req.Header.Set("X-Original-URI", "/orders")
+	rec := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(rec, req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, v.VerifyInputDTO{ClientIp: "198.51.100.9", Method: http.MethodGet, Path: "/"}, usecase.last)
+
+	_, err = handlers.NewDecision(usecase, []string{"not-an-ip"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"time"
+
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	HeaderLimit      = problem.HeaderLimit
+	HeaderRemaining  = problem.HeaderRemaining
+	HeaderReset      = problem.HeaderReset
+	HeaderRetryAfter = problem.HeaderRetryAfter
+	HeaderSchedule   = problem.HeaderSchedule
+
+	// HeaderQuotaPrefix starts the headers of each calendar quota, e.g.
+	// X-Ratelimit-Quota-Month-Remaining
+	HeaderQuotaPrefix = problem.HeaderQuotaPrefix
+)
+
+// setRateLimitHeaders describes the caller's current window on the response
+func setRateLimitHeaders(c *gin.Context, block v.VerifyOutputDTO) {
+	problem.SetHeaders(c.Writer.Header(), block, time.Now())
+}
+
+// abortBlocked writes the body of a refused request in the error format of
+// its service and stops the handler chain. instance is the refused path.
+func abortBlocked(c *gin.Context, status int, block v.VerifyOutputDTO, instance string) {
+	contentType, body := problem.Body(block, status, instance, time.Now())
+	// gin keeps a Content-Type set before rendering JSON
+	c.Header("Content-Type", contentType)
+	c.AbortWithStatusJSON(status, body)
+}
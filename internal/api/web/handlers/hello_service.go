@@ -2,7 +2,8 @@ package handlers
 
 import (
 	"net/http"
-	"ratelim/internal/domain/mydomain/usecase"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/domain/mydomain/usecase"
 
 	"github.com/gin-gonic/gin"
 )
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/infra/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderRequestID carries the request ID, taken from the client when it sends
+// one and echoed on the response
+const HeaderRequestID = "X-Request-Id"
+
+// requestContext returns the request's context carrying its request ID, so
+// every record logged with it can be correlated
+func requestContext(c *gin.Context) context.Context {
+	requestID := c.GetHeader(HeaderRequestID)
+	if requestID == "" {
+		requestID = logging.NewRequestID()
+	}
+	c.Header(HeaderRequestID, requestID)
+	return logging.WithAttrs(c.Request.Context(), slog.String("request_id", requestID))
+}
+
+// withDecision adds the outcome of Verify to the attributes logged with ctx
+func withDecision(ctx context.Context, block v.VerifyOutputDTO) context.Context {
+	return logging.WithAttrs(ctx,
+		slog.String("requester", block.Name),
+		slog.String("key_fingerprint", entity.KeyFingerprint(block.Key)),
+		slog.String("decision", v.Decision(block)),
+	)
+}
@@ -0,0 +1,75 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/handlers"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveLimited(block v.VerifyOutputDTO) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/orders", handlers.NewRateLimiter(&fakeVerify{output: block}).Verify(), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	return rec
+}
+
+func TestRateLimiter_WritesProblemDetails(t *testing.T) {
+	// Arrange
+	resetAt := time.Now().Add(3 * time.Second).Truncate(time.Second)
+	block := v.VerifyOutputDTO{
+		Name: "service-a", Blocked: true, Status: http.StatusTooManyRequests, Message: "limited",
+		Limit: 20, Remaining: 0, ResetAt: resetAt, Code: "rate_limited", Level: "key",
+		ProblemType: "https://docs.example.com/problems/rate_limited",
+	}
+
+	// Act
+	rec := serveLimited(block)
+
+	// Assert
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "https://docs.example.com/problems/rate_limited", body["type"])
+	assert.Equal(t, "Too Many Requests", body["title"])
+	assert.Equal(t, float64(429), body["status"])
+	assert.Equal(t, "limited", body["detail"])
+	assert.Equal(t, "/orders", body["instance"])
+	assert.Equal(t, "service-a", body["service"])
+	assert.Equal(t, "rate_limited", body["code"])
+	assert.Equal(t, float64(20), body["limit"])
+	assert.Equal(t, float64(0), body["remaining"])
+	assert.InDelta(t, 3, body["retry_after"], 1)
+	assert.Equal(t, resetAt.UTC().Format(time.RFC3339), body["reset"])
+}
+
+func TestRateLimiter_WritesLegacyBodyWhenConfigured(t *testing.T) {
+	// Arrange
+	block := v.VerifyOutputDTO{
+		Name: "service-b", Blocked: true, Status: http.StatusForbidden, Message: "Serviço bloqueado",
+		Code: "service_blocked", ErrorFormat: entity.ErrorFormatLegacy,
+	}
+
+	// Act
+	rec := serveLimited(block)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"message":"Serviço bloqueado","code":"service_blocked"}`, rec.Body.String())
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+type proxyRoute struct {
+	prefix string
+	proxy  *httputil.ReverseProxy
+}
+
+// Proxy forwards rate limited requests to the upstream whose prefix matches
+type Proxy struct {
+	routes          []proxyRoute
+	requesterHeader string
+}
+
+func NewProxy(cfg entity.ProxyConfig) (*Proxy, error) {
+	p := &Proxy{requesterHeader: cfg.RequesterHeader}
+
+	for _, upstream := range cfg.Upstreams {
+		target, err := url.Parse(upstream.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid url for upstream '%s': %w", upstream.Prefix, err)
+		}
+		p.routes = append(p.routes, proxyRoute{
+			prefix: upstream.Prefix,
+			proxy:  newReverseProxy(target, upstream.Prefix, upstream.StripPrefix),
+		})
+	}
+
+	// Longest prefix wins
+	sort.Slice(p.routes, func(i, j int) bool {
+		return len(p.routes[i].prefix) > len(p.routes[j].prefix)
+	})
+
+	return p, nil
+}
+
+func newReverseProxy(target *url.URL, prefix string, stripPrefix bool) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			if stripPrefix {
+				path := strings.TrimPrefix(pr.In.URL.Path, strings.TrimSuffix(prefix, "/"))
+				if !strings.HasPrefix(path, "/") {
+					path = "/" + path
+				}
+				pr.Out.URL.Path = path
+				pr.Out.URL.RawPath = ""
+			}
+			pr.SetURL(target)
+			pr.SetXForwarded()
+		},
+	}
+}
+
+// Forward must run after RateLimiter.Verify, which sets the "Requester" key
+func (p *Proxy) Forward(c *gin.Context) {
+	route, ok := p.match(c.Request.URL.Path)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"message": "no upstream configured for this route"})
+		return
+	}
+
+	// Never trust a requester header coming from the client
+	c.Request.Header.Del(p.requesterHeader)
+	if requester := c.GetString("Requester"); requester != "" {
+		c.Request.Header.Set(p.requesterHeader, requester)
+	}
+
+	route.proxy.ServeHTTP(c.Writer, c.Request)
+}
+
+func (p *Proxy) match(path string) (proxyRoute, bool) {
+	for _, route := range p.routes {
+		if entity.MatchesPathPrefix(path, route.prefix) {
+			return route, true
+		}
+	}
+	return proxyRoute{}, false
+}
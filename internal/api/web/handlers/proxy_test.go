@@ -0,0 +1,101 @@
+package handlers_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/handlers"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoUpstream answers with its name, the path it received and the requester header
+func echoUpstream(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, name+" "+r.URL.Path+" "+r.Header.Get(entity.DefaultRequesterHeader))
+	}))
+}
+
+// newProxyServer serves the proxy over a real listener, since ReverseProxy needs
+// a ResponseWriter that supports CloseNotify
+func newProxyServer(t *testing.T, cfg entity.ProxyConfig) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	require.NoError(t, cfg.Validate())
+	proxy, err := handlers.NewProxy(cfg)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("Requester", "service-a")
+		c.Next()
+	})
+	router.NoRoute(proxy.Forward)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(t *testing.T, url string, header http.Header) (int, string) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.Header = header
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestProxy_ForwardsToLongestPrefixWithRequesterHeader(t *testing.T) {
+	// Arrange
+	legacy := echoUpstream("legacy")
+	defer legacy.Close()
+	billing := echoUpstream("billing")
+	defer billing.Close()
+
+	server := newProxyServer(t, entity.ProxyConfig{
+		Enabled: true,
+		Upstreams: []entity.UpstreamConfig{
+			{Prefix: "/", URL: legacy.URL},
+			{Prefix: "/billing", URL: billing.URL, StripPrefix: true},
+		},
+	})
+
+	cases := map[string]string{
+		"/billing/invoices": "billing /invoices service-a",
+		"/billing":          "billing / service-a",
+		"/billingx":         "legacy /billingx service-a",
+		"/users/1":          "legacy /users/1 service-a",
+	}
+
+	for path, expected := range cases {
+		// Act
+		status, body := get(t, server.URL+path, http.Header{entity.DefaultRequesterHeader: {"spoofed"}})
+
+		// Assert
+		assert.Equal(t, http.StatusOK, status, path)
+		assert.Equal(t, expected, body, path)
+	}
+}
+
+func TestProxy_ReturnsNotFoundWithoutMatchingUpstream(t *testing.T) {
+	// Arrange
+	upstream := echoUpstream("api")
+	defer upstream.Close()
+	server := newProxyServer(t, entity.ProxyConfig{
+		Enabled:   true,
+		Upstreams: []entity.UpstreamConfig{{Prefix: "/api", URL: upstream.URL}},
+	})
+
+	// Act
+	status, _ := get(t, server.URL+"/other", http.Header{})
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, status)
+}
@@ -1,11 +1,24 @@
 package handlers
 
 import (
-	v "ratelim/internal/api/web/middleware/ratelimiter/usecase/verify"
+	"net/http"
+	"time"
+
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
 
 	"github.com/gin-gonic/gin"
 )
 
+// CostKey is the gin context key a handler sets (see SetRequestCost) to report
+// the real cost of a request once it knows it
+const CostKey = "RateLimitCost"
+
+// SetRequestCost replaces the cost reserved up front with the real one; the
+// difference is charged or refunded after the handler returns
+func SetRequestCost(c *gin.Context, cost int) {
+	c.Set(CostKey, cost)
+}
+
 type RateLimiter struct {
 	usecase v.VerifyUsecaseInterface
 }
@@ -15,21 +28,66 @@ func NewRateLimiter(usecase v.VerifyUsecaseInterface) *RateLimiter {
 }
 
 func (r *RateLimiter) Verify() gin.HandlerFunc {
+	return r.verify(true)
+}
+
+// VerifyClientIP limits requests by the caller's IP alone, under the default
+// service, so endpoints that must not consume an API key's quota are still limited
+func (r *RateLimiter) VerifyClientIP() gin.HandlerFunc {
+	return r.verify(false)
+}
+
+func (r *RateLimiter) verify(byKey bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		api_key := c.GetHeader("Api-Key")
+		var api_key string
+		if byKey {
+			api_key = c.GetHeader("Api-Key")
+		}
 		client_ip := c.ClientIP()
 
 		input := v.VerifyInputDTO{
 			ApiKey:   api_key,
 			ClientIp: client_ip,
+			Method:   c.Request.Method,
+			Path:     c.Request.URL.Path,
+			Language: c.GetHeader("Accept-Language"),
+
+			TrackInFlight: true,
 		}
-		block := r.usecase.Verify(c.Request.Context(), input)
+		ctx := requestContext(c)
+		block := r.usecase.Verify(ctx, input)
+
+		// Handlers e Settle registram logs com os atributos da requisição
+		c.Request = c.Request.WithContext(withDecision(ctx, block))
+		setRateLimitHeaders(c, block)
 		if block.Blocked {
-			c.JSON(block.Status, gin.H{"message": block.Message})
-			c.AbortWithStatus(block.Status)
+			abortBlocked(c, block.Status, block, c.Request.URL.Path)
 			return
 		}
 
+		// Liberar o slot de concorrência mesmo se o handler entrar em pânico
+		if block.Release != nil {
+			defer block.Release()
+		}
+
+		// Liquidar a cota reservada quando a resposta for conhecida; um pânico
+		// conta como erro do servidor
+		started := time.Now()
+		defer func() {
+			result := v.SettleInputDTO{
+				Status:  c.Writer.Status(),
+				Size:    int64(c.Writer.Size()),
+				Cost:    c.GetInt(CostKey),
+				Latency: time.Since(started),
+			}
+			if rec := recover(); rec != nil {
+				result.Status = http.StatusInternalServerError
+				_ = r.usecase.Settle(c.Request.Context(), block, result)
+				panic(rec)
+			}
+			_ = r.usecase.Settle(c.Request.Context(), block, result)
+		}()
+
 		c.Set("Requester", block.Name)
 
 		c.Next()
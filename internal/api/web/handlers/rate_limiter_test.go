@@ -0,0 +1,118 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/handlers"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/messages"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// settlingVerify returns a fixed decision whose slot release is counted, and
+// records what the middleware settles
+type settlingVerify struct {
+	output   v.VerifyOutputDTO
+	released int
+	settled  []v.SettleInputDTO
+}
+
+func (f *settlingVerify) Verify(ctx context.Context, input v.VerifyInputDTO) v.VerifyOutputDTO {
+	out := f.output
+	out.Release = func() { f.released++ }
+	return out
+}
+
+func (f *settlingVerify) Settle(ctx context.Context, verified v.VerifyOutputDTO, result v.SettleInputDTO) error {
+	f.settled = append(f.settled, result)
+	return nil
+}
+
+func newLimitedRouter(usecase v.VerifyUsecaseInterface, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/hello", handlers.NewRateLimiter(usecase).Verify(), handler)
+	return router
+}
+
+func TestRateLimiter_PanicReleasesSlotAndIsReraised(t *testing.T) {
+	// Arrange
+	usecase := &settlingVerify{output: v.VerifyOutputDTO{Name: "reports", Status: http.StatusOK}}
+	router := newLimitedRouter(usecase, func(c *gin.Context) {
+		panic("boom")
+	})
+
+	// Act
+	serve := func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hello", nil))
+	}
+
+	// Assert
+	assert.PanicsWithValue(t, "boom", serve)
+	assert.Equal(t, 1, usecase.released)
+	require.Len(t, usecase.settled, 1)
+	assert.Equal(t, http.StatusInternalServerError, usecase.settled[0].Status)
+}
+
+func TestRateLimiter_SettlesAdmittedRequestsAndSetsHeaders(t *testing.T) {
+	// Arrange
+	resetAt := time.Now().Add(2 * time.Second)
+	usecase := &settlingVerify{output: v.VerifyOutputDTO{
+		Name: "bulk", Status: http.StatusOK, Limit: 100, Remaining: 60, ResetAt: resetAt, Cost: 40,
+		Quotas: []v.QuotaUsage{{Period: "month", Limit: 1000, Remaining: 900, ResetAt: resetAt}},
+	}}
+	var requester string
+	router := newLimitedRouter(usecase, func(c *gin.Context) {
+		requester = c.GetString("Requester")
+		handlers.SetRequestCost(c, 10)
+		c.String(http.StatusCreated, "done")
+	})
+	rec := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hello", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "bulk", requester)
+	assert.Equal(t, "100", rec.Header().Get(handlers.HeaderLimit))
+	assert.Equal(t, "60", rec.Header().Get(handlers.HeaderRemaining))
+	assert.Equal(t, "900", rec.Header().Get("X-Ratelimit-Quota-Month-Remaining"))
+	assert.Empty(t, rec.Header().Get(handlers.HeaderRetryAfter))
+	assert.Equal(t, 1, usecase.released)
+	require.Len(t, usecase.settled, 1)
+	assert.Equal(t, http.StatusCreated, usecase.settled[0].Status)
+	assert.Equal(t, int64(4), usecase.settled[0].Size)
+	assert.Equal(t, 10, usecase.settled[0].Cost)
+}
+
+func TestRateLimiter_BlockedRequestsNeverReachTheHandler(t *testing.T) {
+	// Arrange
+	usecase := &settlingVerify{output: v.VerifyOutputDTO{
+		Name: "reports", Blocked: true, Status: http.StatusTooManyRequests, Code: messages.RateLimited,
+		Limit: 2, ResetAt: time.Now().Add(2 * time.Second),
+	}}
+	called := false
+	router := newLimitedRouter(usecase, func(c *gin.Context) { called = true })
+	rec := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hello", nil))
+
+	// Assert
+	assert.False(t, called)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+	assert.NotEmpty(t, rec.Header().Get(handlers.HeaderRetryAfter))
+	assert.Equal(t, "0", rec.Header().Get(handlers.HeaderRemaining))
+	assert.Empty(t, usecase.settled)
+	assert.Zero(t, usecase.released)
+}
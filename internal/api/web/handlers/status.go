@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Status lets API consumers check the usage of their own limits
+type Status struct {
+	usecase v.StatusUsecaseInterface
+}
+
+func NewStatus(usecase v.StatusUsecaseInterface) *Status {
+	return &Status{usecase: usecase}
+}
+
+// Get reports every limit of the service the Api-Key belongs to, with its
+// usage, remaining amount and reset time, without counting the request
+func (s *Status) Get(c *gin.Context) {
+	input := v.VerifyInputDTO{
+		ApiKey:   c.GetHeader("Api-Key"),
+		ClientIp: c.ClientIP(),
+		Method:   c.Request.Method,
+		Path:     c.Request.URL.Path,
+		Language: c.GetHeader("Accept-Language"),
+	}
+
+	status := s.usecase.Status(requestContext(c), input)
+	if status.Refusal.Blocked {
+		abortBlocked(c, status.Refusal.Status, status.Refusal, input.Path)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
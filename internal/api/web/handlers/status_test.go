@@ -0,0 +1,108 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/handlers"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/messages"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStatus returns the status registered for each API key
+type fakeStatus struct {
+	statuses map[string]v.StatusOutputDTO
+}
+
+func (f *fakeStatus) Status(ctx context.Context, input v.VerifyInputDTO) v.StatusOutputDTO {
+	if status, ok := f.statuses[input.ApiKey]; ok {
+		return status
+	}
+	return v.StatusOutputDTO{Refusal: v.VerifyOutputDTO{
+		Blocked: true, Status: http.StatusUnauthorized, Code: messages.InvalidAPIKey, Message: "Chave de API ausente ou não cadastrada",
+	}}
+}
+
+func TestStatus_ReportsLimitsOfTheCallersKey(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	resetAt := time.Date(2025, 6, 10, 12, 0, 2, 0, time.UTC)
+	status := handlers.NewStatus(&fakeStatus{statuses: map[string]v.StatusOutputDTO{
+		"partn3r": {
+			Name:   "partner",
+			Limits: []v.LimitStatus{{Name: "allowed_rps", Level: "key", Limit: 2, Used: 2, Remaining: 0, ResetAt: resetAt}},
+			Block:  &v.BlockStatus{Code: messages.RateLimited, Message: "Rate limit excedido", Until: resetAt},
+		},
+	}})
+	router := gin.New()
+	router.GET("/ratelimit/status", status.Get)
+
+	do := func(apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/ratelimit/status", nil)
+		req.Header.Set("Api-Key", apiKey)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Act
+	known := do("partn3r")
+	unknown := do("guess")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, known.Code)
+	assert.JSONEq(t, `{
+		"service": "partner",
+		"limits": [{"name":"allowed_rps","level":"key","limit":2,"used":2,"remaining":0,"reset_at":"2025-06-10T12:00:02Z"}],
+		"block": {"code":"rate_limited","message":"Rate limit excedido","until":"2025-06-10T12:00:02Z"}
+	}`, known.Body.String())
+
+	assert.Equal(t, http.StatusUnauthorized, unknown.Code)
+	assert.Equal(t, problem.ContentType, unknown.Header().Get("Content-Type"))
+	assert.Contains(t, unknown.Body.String(), `"code":"invalid_api_key"`)
+}
+
+// ipVerifier records the inputs it verifies and admits them
+type ipVerifier struct {
+	inputs []v.VerifyInputDTO
+}
+
+func (f *ipVerifier) Verify(ctx context.Context, input v.VerifyInputDTO) v.VerifyOutputDTO {
+	f.inputs = append(f.inputs, input)
+	return v.VerifyOutputDTO{Status: http.StatusOK}
+}
+
+func (f *ipVerifier) Settle(ctx context.Context, verified v.VerifyOutputDTO, result v.SettleInputDTO) error {
+	return nil
+}
+
+func TestStatus_IsLimitedByTheCallersIP(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	verifier := &ipVerifier{}
+	limiter := handlers.NewRateLimiter(verifier)
+	status := handlers.NewStatus(&fakeStatus{})
+	router := gin.New()
+	router.GET("/ratelimit/status", limiter.VerifyClientIP(), status.Get)
+
+	req := httptest.NewRequest(http.MethodGet, "/ratelimit/status", nil)
+	req.Header.Set("Api-Key", "partn3r")
+	req.RemoteAddr = "203.0.113.7:5000"
+	rec := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(rec, req)
+
+	// Assert
+	if assert.Len(t, verifier.inputs, 1) {
+		assert.Empty(t, verifier.inputs[0].ApiKey)
+		assert.Equal(t, "203.0.113.7", verifier.inputs[0].ClientIp)
+	}
+}
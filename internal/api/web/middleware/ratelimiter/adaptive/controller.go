@@ -0,0 +1,165 @@
+package adaptive
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+)
+
+// idleTTL is how long the state of a key that sees no traffic is kept
+const idleTTL = 10 * time.Minute
+
+// Controller keeps the effective limit of every adaptive service, adjusting it
+// from the requests observed by this instance (AIMD)
+type Controller struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	states    map[string]*state
+	lastSweep time.Time
+}
+
+type state struct {
+	name      string
+	config    entity.AdaptiveConfig
+	limit     int
+	started   time.Time
+	lastSeen  time.Time
+	requests  int
+	errors    int
+	latencies time.Duration
+}
+
+// Limit is the effective limit of an adaptive service, as shown by the admin
+// API; the key itself is never exposed, only its fingerprint
+type Limit struct {
+	Name           string `json:"name"`
+	KeyFingerprint string `json:"key_fingerprint"`
+	Effective      int    `json:"effective_rps"`
+	Min            int    `json:"min_rps"`
+	Max            int    `json:"max_rps"`
+}
+
+func NewController(now func() time.Time) *Controller {
+	return &Controller{now: now, states: make(map[string]*state)}
+}
+
+// Limit returns the effective limit of key, served by config. The first call
+// starts it at allowed_rps, clamped to the service's bounds.
+func (c *Controller) Limit(key string, config entity.ServiceConfig) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.sweep(now)
+
+	s, ok := c.states[key]
+	if !ok {
+		s = &state{
+			limit:   min(max(config.AllowedRPS, config.Adaptive.MinRPS), config.Adaptive.MaxRPS),
+			started: now,
+		}
+		c.states[key] = s
+	}
+	// A config recarregada passa a valer a partir daqui
+	s.name = config.Name
+	s.config = config.Adaptive
+	s.limit = min(max(s.limit, s.config.MinRPS), s.config.MaxRPS)
+	s.lastSeen = now
+
+	s.evaluate(now)
+	return s.limit
+}
+
+// Observe records how a request admitted under Limit ended
+func (c *Controller) Observe(key string, latency time.Duration, status int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.states[key]
+	if !ok {
+		return
+	}
+	s.requests++
+	s.latencies += latency
+	if status >= http.StatusInternalServerError {
+		s.errors++
+	}
+	s.evaluate(c.now())
+}
+
+// Snapshot lists the effective limit of every adaptive key, sorted by name
+func (c *Controller) Snapshot() []Limit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	limits := make([]Limit, 0, len(c.states))
+	for key, s := range c.states {
+		limits = append(limits, Limit{
+			Name:           s.name,
+			KeyFingerprint: entity.KeyFingerprint(key),
+			Effective:      s.limit,
+			Min:            s.config.MinRPS,
+			Max:            s.config.MaxRPS,
+		})
+	}
+	sort.Slice(limits, func(i, j int) bool {
+		if limits[i].Name != limits[j].Name {
+			return limits[i].Name < limits[j].Name
+		}
+		return limits[i].KeyFingerprint < limits[j].KeyFingerprint
+	})
+	return limits
+}
+
+// Effective returns the effective limit of key without counting it as traffic,
+// and false when the key was not seen yet
+func (c *Controller) Effective(key string) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.states[key]
+	if !ok {
+		return 0, false
+	}
+	return s.limit, true
+}
+
+// evaluate applies the AIMD step once the interval is over: additive increase
+// while the backend is healthy, multiplicative decrease when it is not
+func (s *state) evaluate(now time.Time) {
+	if now.Sub(s.started) < s.config.EvaluationInterval() {
+		return
+	}
+
+	if s.requests > 0 {
+		avgLatency := s.latencies / time.Duration(s.requests)
+		errorRate := float64(s.errors) / float64(s.requests)
+		if avgLatency > s.config.Target() || errorRate > s.config.ErrorRate() {
+			s.limit = max(int(float64(s.limit)*s.config.Factor()), s.config.MinRPS)
+		} else {
+			s.limit = min(s.limit+s.config.Step(), s.config.MaxRPS)
+		}
+	}
+
+	s.started = now
+	s.requests = 0
+	s.errors = 0
+	s.latencies = 0
+}
+
+// sweep forgets keys that went quiet, e.g. IPs served by an adaptive default
+func (c *Controller) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < idleTTL {
+		return
+	}
+	c.lastSweep = now
+	for key, s := range c.states {
+		if now.Sub(s.lastSeen) > idleTTL {
+			delete(c.states, key)
+		}
+	}
+}
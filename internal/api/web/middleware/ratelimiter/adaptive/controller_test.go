@@ -0,0 +1,104 @@
+package adaptive_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/adaptive"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func adaptiveService() entity.ServiceConfig {
+	return entity.ServiceConfig{
+		Name:       "search",
+		Key:        "s3arch",
+		AllowedRPS: 10,
+		Adaptive: entity.AdaptiveConfig{
+			Enabled:       true,
+			MinRPS:        4,
+			MaxRPS:        12,
+			TargetLatency: "100ms",
+			Increase:      2,
+		},
+	}
+}
+
+func TestController_IncreasesAdditivelyWhileHealthy(t *testing.T) {
+	// Arrange
+	clk := &clock{t: time.Unix(1000, 0)}
+	controller := adaptive.NewController(clk.now)
+	config := adaptiveService()
+
+	// Act
+	initial := controller.Limit("s3arch", config)
+	var limits []int
+	for i := 0; i < 2; i++ {
+		controller.Observe("s3arch", 20*time.Millisecond, 200)
+		clk.t = clk.t.Add(time.Second)
+		limits = append(limits, controller.Limit("s3arch", config))
+	}
+
+	// Assert
+	assert.Equal(t, 10, initial)
+	// 10 + 2, then capped at max_rps
+	assert.Equal(t, []int{12, 12}, limits)
+}
+
+func TestController_DecreasesMultiplicativelyOnLatencyAndErrors(t *testing.T) {
+	// Arrange
+	clk := &clock{t: time.Unix(1000, 0)}
+	controller := adaptive.NewController(clk.now)
+	config := adaptiveService()
+	controller.Limit("s3arch", config)
+
+	// Act
+	controller.Observe("s3arch", 300*time.Millisecond, 200)
+	clk.t = clk.t.Add(time.Second)
+	afterSlow := controller.Limit("s3arch", config)
+
+	controller.Observe("s3arch", 10*time.Millisecond, 503)
+	clk.t = clk.t.Add(time.Second)
+	afterErrors := controller.Limit("s3arch", config)
+
+	// Assert
+	assert.Equal(t, 5, afterSlow)
+	// Halving 5 would go under min_rps
+	assert.Equal(t, 4, afterErrors)
+	assert.Equal(t, []adaptive.Limit{{Name: "search", KeyFingerprint: entity.KeyFingerprint("s3arch"), Effective: 4, Min: 4, Max: 12}}, controller.Snapshot())
+}
+
+func TestController_ExplicitZeroMaxErrorRateDecreasesOnAnyError(t *testing.T) {
+	// Arrange
+	clk := &clock{t: time.Unix(1000, 0)}
+	controller := adaptive.NewController(clk.now)
+	strict, lenient := adaptiveService(), adaptiveService()
+	zero := 0.0
+	strict.Adaptive.MaxErrorRate = &zero
+	lenient.Key = "l3nient"
+	controller.Limit("s3arch", strict)
+	controller.Limit("l3nient", lenient)
+
+	// Act
+	// One error in 20 requests: 5%, under the default of 10%
+	for i := 0; i < 20; i++ {
+		status := 200
+		if i == 0 {
+			status = 503
+		}
+		controller.Observe("s3arch", 10*time.Millisecond, status)
+		controller.Observe("l3nient", 10*time.Millisecond, status)
+	}
+	clk.t = clk.t.Add(time.Second)
+	strictLimit := controller.Limit("s3arch", strict)
+	lenientLimit := controller.Limit("l3nient", lenient)
+
+	// Assert
+	assert.Equal(t, 5, strictLimit)
+	assert.Equal(t, 12, lenientLimit)
+}
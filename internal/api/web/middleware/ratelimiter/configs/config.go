@@ -1,13 +1,15 @@
 package configs
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"path/filepath"
-	"ratelim/internal/api/web/middleware/ratelimiter/entity"
 	"runtime"
 	"strings"
 
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+
 	"github.com/spf13/viper"
 )
 
@@ -47,13 +49,47 @@ func LoadConfig(configPath string) (*entity.Config, error) {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
 
+	// Registrar as chaves presentes em cada serviço: valores zero explícitos
+	// (ex.: shaping: false) prevalecem sobre o plano
+	if raw, ok := v.Get("services").([]interface{}); ok {
+		for i, item := range raw {
+			fields, ok := item.(map[string]interface{})
+			if !ok || i >= len(cfg.Services) || cfg.Services[i] == nil {
+				continue
+			}
+			cfg.Services[i].SetFields = make(map[string]bool, len(fields))
+			for field := range fields {
+				cfg.Services[i].SetFields[strings.ToLower(field)] = true
+			}
+		}
+	}
+
 	// Validate the config
 	errs := cfg.Validate()
 	if len(cfg.Services) == 0 {
 		return nil, fmt.Errorf("error validating config: %w", errs[0])
 	}
 	for _, err := range errs {
-		log.Printf("%s", err.Error())
+		// Um plano inexistente indica erro de digitação, não um serviço a descartar
+		if errors.Is(err, entity.ErrUnknownPlan) {
+			return nil, fmt.Errorf("error validating config: %w", err)
+		}
+		slog.Warn("configuração de serviço inválida", "error", err)
+	}
+
+	if err := cfg.Global.Validate(); err != nil {
+		return nil, fmt.Errorf("error validating global config: %w", err)
+	}
+	if err := cfg.Global.Apply(cfg.Services); err != nil {
+		return nil, fmt.Errorf("error validating global config: %w", err)
+	}
+
+	if err := cfg.Shedding.Validate(); err != nil {
+		return nil, fmt.Errorf("error validating shedding config: %w", err)
+	}
+
+	if err := cfg.Proxy.Validate(); err != nil {
+		return nil, fmt.Errorf("error validating proxy config: %w", err)
 	}
 
 	return &cfg, nil
@@ -1,8 +1,11 @@
 package configs_test
 
 import (
-	"ratelim/internal/api/web/middleware/ratelimiter/configs"
 	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/configs"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -84,3 +87,148 @@ func TestLoadConfig_PassForProperConfig(t *testing.T) {
 	assert.Equal(t, cfg.Services[4].AllowedRPS, 10)
 	assert.Equal(t, cfg.Services[4].WaitTimeIfLimitExceeded, "5m")
 }
+
+func TestLoadConfig_RouteCostsAreInheritedAndValidated(t *testing.T) {
+	// Arrange
+	cfg, err := configs.LoadConfig("services_fifth_test.yaml")
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(cfg.Services))
+	// Service A inherits the default routes
+	assert.Equal(t, 100, cfg.Services[1].CostFor("GET", "/bulk/users"))
+	assert.Equal(t, 1, cfg.Services[1].CostFor("GET", "/bulkx"))
+	// Service B keeps its own routes
+	assert.Equal(t, 5, cfg.Services[2].CostFor("POST", "/orders"))
+	assert.Equal(t, 1, cfg.Services[2].CostFor("GET", "/orders"))
+	assert.Equal(t, 1, cfg.Services[2].CostFor("GET", "/bulk"))
+	// Service D only switches a route to shadow mode, keeping the default cost
+	assert.Equal(t, "service-d", cfg.Services[3].Name)
+	assert.Equal(t, 1, cfg.Services[3].CostFor("GET", "/beta/items"))
+	assert.True(t, cfg.Services[3].ShadowFor("GET", "/beta/items"))
+}
+
+func TestLoadConfig_ServicesInheritAndOverridePlans(t *testing.T) {
+	// Arrange
+	cfg, err := configs.LoadConfig("services_sixth_test.yaml")
+
+	// Assert
+	assert.Nil(t, err)
+	// Service E references an unknown group
+	assert.Equal(t, 6, len(cfg.Services))
+	assert.Equal(t, 5, cfg.Services[0].AllowedRPS)
+	assert.Equal(t, "5m", cfg.Services[0].WaitTimeIfLimitExceeded)
+	assert.Equal(t, 100, cfg.Services[1].AllowedRPS)
+	assert.Equal(t, "10s", cfg.Services[1].WaitTimeIfLimitExceeded)
+	assert.Equal(t, 20, cfg.Services[1].MaxConcurrent)
+	// Service B overrides the plan's allowed_rps and keeps the rest
+	assert.Equal(t, 200, cfg.Services[2].AllowedRPS)
+	assert.Equal(t, "10s", cfg.Services[2].WaitTimeIfLimitExceeded)
+	assert.Equal(t, 20, cfg.Services[2].MaxConcurrent)
+	// Service F switches plan fields off with explicit zero values
+	assert.Equal(t, "service-f", cfg.Services[4].Name)
+	assert.Equal(t, 50, cfg.Services[4].AllowedRPS)
+	assert.Equal(t, "2s", cfg.Services[4].MaxDelay)
+	assert.False(t, cfg.Services[4].Shaping)
+	assert.False(t, cfg.Services[4].RefundServerErrors)
+	assert.Equal(t, 0, cfg.Services[4].MaxConcurrent)
+	assert.Equal(t, 0, cfg.Services[4].Priority)
+	// Service G turns the plan's allowed_rps off instead of taking the default's
+	assert.Equal(t, "service-g", cfg.Services[5].Name)
+	assert.Equal(t, 0, cfg.Services[5].AllowedRPS)
+	assert.Equal(t, "10s", cfg.Services[5].WaitTimeIfLimitExceeded)
+}
+
+func TestLoadConfig_UnknownPlanIsAnError(t *testing.T) {
+	// Arrange
+	cfg, err := configs.LoadConfig("services_ninth_test.yaml")
+
+	// Assert
+	assert.Nil(t, cfg)
+	assert.ErrorIs(t, err, entity.ErrUnknownPlan)
+	assert.EqualError(t, err, "error validating config: unknown plan 'platinum' for service 'service-c'")
+}
+
+func TestLoadConfig_ServicesGetTheirGroupLimits(t *testing.T) {
+	// Arrange
+	cfg, err := configs.LoadConfig("services_sixth_test.yaml")
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "service-d", cfg.Services[3].Name)
+	assert.Equal(t, "acme", cfg.Services[3].Group)
+	assert.Equal(t, 50, cfg.Services[3].GroupAllowedRPS)
+	assert.Equal(t, 0, cfg.Services[1].GroupAllowedRPS)
+}
+
+func TestLoadConfig_GlobalLimitIsSharedOutByPriority(t *testing.T) {
+	// Arrange
+	cfg, err := configs.LoadConfig("services_sixth_test.yaml")
+
+	// Assert
+	assert.Nil(t, err)
+	// Default only gets the unreserved share
+	assert.Equal(t, 800, cfg.Services[0].GlobalAllowedRPS)
+	assert.Equal(t, 1000, cfg.Services[1].GlobalAllowedRPS)
+	assert.False(t, cfg.Services[1].GlobalBypass)
+	assert.True(t, cfg.Services[2].GlobalBypass)
+}
+
+func TestLoadConfig_ExplicitZeroShedFromIsKept(t *testing.T) {
+	// Arrange
+	cfg, err := configs.LoadConfig("services_sixth_test.yaml")
+
+	// Assert
+	assert.Nil(t, err)
+	if assert.NotNil(t, cfg.Shedding.ShedFrom) {
+		assert.Equal(t, 0.0, *cfg.Shedding.ShedFrom)
+	}
+	assert.Equal(t, 0.0, cfg.Shedding.Threshold(0))
+}
+
+func TestLoadConfig_ExplicitZeroMaxErrorRateIsKept(t *testing.T) {
+	// Arrange
+	cfg, err := configs.LoadConfig("services_sixth_test.yaml")
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "service-d", cfg.Services[3].Name)
+	assert.Equal(t, 0.0, cfg.Services[3].Adaptive.ErrorRate())
+	assert.Equal(t, entity.DefaultAdaptiveMaxErrorRate, cfg.Services[1].Adaptive.ErrorRate())
+}
+
+func TestLoadConfig_SchedulesAreValidatedAndResolvedInTheirTimezone(t *testing.T) {
+	// Arrange
+	cfg, err := configs.LoadConfig("services_seventh_test.yaml")
+	saoPaulo, _ := time.LoadLocation("America/Sao_Paulo")
+
+	// Act
+	// Friday 10:00 and Saturday 10:00 in São Paulo, given in UTC
+	weekday := cfg.Services[1].ActiveSchedule(time.Date(2025, 6, 6, 10, 0, 0, 0, saoPaulo).UTC())
+	weekend := cfg.Services[1].ActiveSchedule(time.Date(2025, 6, 7, 10, 0, 0, 0, saoPaulo).UTC())
+
+	// Assert
+	assert.Nil(t, err)
+	// Services B and C have an unknown timezone and an invalid hour
+	assert.Equal(t, 2, len(cfg.Services))
+	assert.NotNil(t, weekday)
+	assert.Equal(t, "business-hours", weekday.Name)
+	assert.Nil(t, weekend)
+}
+
+func TestLoadConfig_LanguagesMessagesAndErrorFormatsAreValidated(t *testing.T) {
+	// Arrange & Act
+	cfg, err := configs.LoadConfig("services_eighth_test.yaml")
+
+	// Assert
+	assert.Nil(t, err)
+	// Service B has an unsupported language, C a broken template and D an unknown error format
+	assert.Equal(t, 2, len(cfg.Services))
+	assert.Equal(t, "en", cfg.Services[0].Language)
+	assert.Equal(t, "pt-BR", cfg.Services[1].Language)
+	assert.Contains(t, cfg.Services[1].Messages["en"], "rate_limited")
+
+	assert.Equal(t, "legacy", cfg.Services[0].ErrorFormat)
+	assert.Equal(t, "problem", cfg.Services[1].ErrorFormat)
+	assert.Equal(t, "https://docs.example.com/problems/quota_exceeded", cfg.Services[1].ProblemType("quota_exceeded"))
+}
@@ -0,0 +1,96 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// Adaptive defaults
+const (
+	DefaultAdaptiveIncrease       = 1
+	DefaultAdaptiveDecreaseFactor = 0.5
+	DefaultAdaptiveMaxErrorRate   = 0.1
+	DefaultAdaptiveInterval       = time.Second
+)
+
+// AdaptiveConfig lets the service's limit follow the health of its backend
+// (AIMD). Every Interval the latency and 5xx rate of its requests are checked:
+// while the average latency stays under TargetLatency and the error rate under
+// MaxErrorRate the limit grows by Increase, otherwise it is multiplied by
+// DecreaseFactor. The limit starts at allowed_rps and stays within
+// [MinRPS, MaxRPS]. Zero values use the defaults above, except for
+// MaxErrorRate: it is a pointer so that an explicit 0 (any 5xx is unhealthy)
+// is told apart from an unset value.
+type AdaptiveConfig struct {
+	Enabled        bool     `mapstructure:"enabled"`
+	MinRPS         int      `mapstructure:"min_rps"`
+	MaxRPS         int      `mapstructure:"max_rps"`
+	TargetLatency  string   `mapstructure:"target_latency"`
+	MaxErrorRate   *float64 `mapstructure:"max_error_rate"`
+	Increase       int      `mapstructure:"increase"`
+	DecreaseFactor float64  `mapstructure:"decrease_factor"`
+	Interval       string   `mapstructure:"interval"`
+}
+
+// Target returns the parsed TargetLatency
+func (a AdaptiveConfig) Target() time.Duration {
+	d, _ := time.ParseDuration(a.TargetLatency)
+	return d
+}
+
+// Step returns how much the limit grows per healthy interval
+func (a AdaptiveConfig) Step() int {
+	if a.Increase <= 0 {
+		return DefaultAdaptiveIncrease
+	}
+	return a.Increase
+}
+
+// Factor returns what the limit is multiplied by when the backend degrades
+func (a AdaptiveConfig) Factor() float64 {
+	if a.DecreaseFactor <= 0 {
+		return DefaultAdaptiveDecreaseFactor
+	}
+	return a.DecreaseFactor
+}
+
+// ErrorRate returns the highest healthy 5xx rate
+func (a AdaptiveConfig) ErrorRate() float64 {
+	if a.MaxErrorRate == nil {
+		return DefaultAdaptiveMaxErrorRate
+	}
+	return *a.MaxErrorRate
+}
+
+// EvaluationInterval returns the parsed Interval, falling back to the default
+func (a AdaptiveConfig) EvaluationInterval() time.Duration {
+	d, err := time.ParseDuration(a.Interval)
+	if err != nil || d <= 0 {
+		return DefaultAdaptiveInterval
+	}
+	return d
+}
+
+func (a AdaptiveConfig) validate() error {
+	if !a.Enabled {
+		return nil
+	}
+	if a.MinRPS < 1 || a.MaxRPS < a.MinRPS {
+		return errors.New("min_rps must be >= 1 and max_rps >= min_rps")
+	}
+	if d, err := time.ParseDuration(a.TargetLatency); err != nil || d <= 0 {
+		return errors.New("target_latency must be a positive duration")
+	}
+	if a.MaxErrorRate != nil && (*a.MaxErrorRate < 0 || *a.MaxErrorRate > 1) {
+		return errors.New("max_error_rate must be between 0 and 1")
+	}
+	if a.DecreaseFactor < 0 || a.DecreaseFactor >= 1 {
+		return errors.New("decrease_factor must be between 0 and 1")
+	}
+	if a.Interval != "" {
+		if d, err := time.ParseDuration(a.Interval); err != nil || d <= 0 {
+			return errors.New("interval must be a positive duration")
+		}
+	}
+	return nil
+}
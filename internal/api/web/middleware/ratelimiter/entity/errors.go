@@ -0,0 +1,22 @@
+package entity
+
+// Formats of the body of refused requests
+const (
+	// ErrorFormatProblem writes RFC 9457 application/problem+json bodies
+	ErrorFormatProblem = "problem"
+	// ErrorFormatLegacy writes the former {"message": ...} bodies
+	ErrorFormatLegacy = "legacy"
+)
+
+func validErrorFormat(format string) bool {
+	return format == "" || format == ErrorFormatProblem || format == ErrorFormatLegacy
+}
+
+// ProblemType is the "type" member of problem+json bodies for code: the
+// configured base followed by the code, or "about:blank" without a base
+func (s ServiceConfig) ProblemType(code string) string {
+	if s.ProblemTypeBase == "" || code == "" {
+		return "about:blank"
+	}
+	return s.ProblemTypeBase + code
+}
@@ -0,0 +1,13 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// KeyFingerprint identifies a rate limit key (API key or client IP) in logs and
+// audit events without revealing it: the first 8 bytes of its SHA-256, in hex
+func KeyFingerprint(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
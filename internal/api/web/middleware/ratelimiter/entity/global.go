@@ -0,0 +1,67 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+)
+
+// GlobalConfig caps the requests admitted per second across all callers, so a
+// flood spread over many IPs or keys cannot overwhelm the backend
+type GlobalConfig struct {
+	AllowedRPS int `mapstructure:"allowed_rps"`
+	// ReservedShare is the fraction of AllowedRPS (e.g. 0.2) kept for priority
+	// services; the others are blocked once the rest is used up
+	ReservedShare    float64           `mapstructure:"reserved_share"`
+	PriorityServices []PriorityService `mapstructure:"priority_services"`
+}
+
+// PriorityService may use the reserved share of the global limit or, with
+// Bypass, is never blocked by it. Its requests still count towards it.
+type PriorityService struct {
+	Name   string `mapstructure:"name"`
+	Bypass bool   `mapstructure:"bypass"`
+}
+
+func (g *GlobalConfig) Validate() error {
+	if g.AllowedRPS < 0 {
+		return errors.New("global allowed_rps must be >= 0")
+	}
+	if g.ReservedShare < 0 || g.ReservedShare >= 1 {
+		return errors.New("global reserved_share must be >= 0 and < 1")
+	}
+	for _, p := range g.PriorityServices {
+		if p.Name == "" {
+			return errors.New("global priority service name cannot be empty")
+		}
+	}
+	return nil
+}
+
+// Apply sets the global limit each service is held to: the full AllowedRPS for
+// priority services, AllowedRPS minus the reserved share for the others
+func (g *GlobalConfig) Apply(services []*ServiceConfig) error {
+	if g.AllowedRPS == 0 {
+		return nil
+	}
+
+	priority := make(map[string]PriorityService)
+	for _, p := range g.PriorityServices {
+		priority[p.Name] = p
+	}
+
+	shared := max(int(float64(g.AllowedRPS)*(1-g.ReservedShare)), 1)
+	for _, s := range services {
+		p, ok := priority[s.Name]
+		s.GlobalBypass = ok && p.Bypass
+		s.GlobalAllowedRPS = shared
+		if ok {
+			s.GlobalAllowedRPS = g.AllowedRPS
+		}
+		delete(priority, s.Name)
+	}
+
+	for name := range priority {
+		return fmt.Errorf("unknown global priority service '%s'", name)
+	}
+	return nil
+}
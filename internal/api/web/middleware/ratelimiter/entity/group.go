@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GroupConfig is the quota shared by every service of an organization. A
+// request must pass both its own limit and its group's.
+type GroupConfig struct {
+	AllowedRPS int `mapstructure:"allowed_rps"`
+}
+
+// Groups maps a group name to its shared limits
+type Groups map[string]GroupConfig
+
+// applyGroup copies the limits of the group the service belongs to into it
+func (g Groups) applyGroup(s *ServiceConfig) error {
+	if s.Group == "" {
+		return nil
+	}
+	group, ok := g[s.Group]
+	if !ok {
+		// viper lowercases map keys when loading the file
+		group, ok = g[strings.ToLower(s.Group)]
+	}
+	if !ok {
+		return fmt.Errorf("unknown group '%s' for service '%s'", s.Group, s.Name)
+	}
+	if group.AllowedRPS <= 0 {
+		return fmt.Errorf("allowed_rps must be > 0 for group '%s'", s.Group)
+	}
+	s.GroupAllowedRPS = group.AllowedRPS
+	return nil
+}
@@ -0,0 +1,105 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownPlan is returned for services that reference a plan that does not
+// exist. Unlike other service errors, it invalidates the whole config.
+var ErrUnknownPlan = errors.New("unknown plan")
+
+// Plans maps a plan name to a bundle of limits. Only the limit fields of the
+// ServiceConfig are used; identity fields (name, type, key, address, valid) are
+// ignored.
+type Plans map[string]ServiceConfig
+
+// applyPlan fills every limit field the service left unset with the value of
+// the plan it references. Fields set on the service override the plan, even
+// when set to their zero value (e.g. shaping: false).
+func (p Plans) applyPlan(s *ServiceConfig) error {
+	if s.Plan == "" {
+		return nil
+	}
+	plan, ok := p[s.Plan]
+	if !ok {
+		// viper lowercases map keys when loading the file
+		plan, ok = p[strings.ToLower(s.Plan)]
+	}
+	if !ok {
+		return fmt.Errorf("%w '%s' for service '%s'", ErrUnknownPlan, s.Plan, s.Name)
+	}
+
+	if s.inherits("allowed_rps", s.AllowedRPS == 0) {
+		s.AllowedRPS = plan.AllowedRPS
+	}
+	if s.inherits("wait_time_if_limit_exceeded", s.WaitTimeIfLimitExceeded == "") {
+		s.WaitTimeIfLimitExceeded = plan.WaitTimeIfLimitExceeded
+	}
+	if s.inherits("local_sync_interval", s.LocalSyncInterval == "") {
+		s.LocalSyncInterval = plan.LocalSyncInterval
+	}
+	if s.inherits("max_concurrent", s.MaxConcurrent == 0) {
+		s.MaxConcurrent = plan.MaxConcurrent
+	}
+	if s.inherits("concurrency_lease_ttl", s.ConcurrencyLeaseTTL == "") {
+		s.ConcurrencyLeaseTTL = plan.ConcurrencyLeaseTTL
+	}
+	if s.inherits("routes", len(s.Routes) == 0) {
+		s.Routes = plan.Routes
+	}
+	if s.inherits("refund_server_errors", !s.RefundServerErrors) {
+		s.RefundServerErrors = plan.RefundServerErrors
+	}
+	if s.inherits("max_bytes_per_minute", s.MaxBytesPerMinute == 0) {
+		s.MaxBytesPerMinute = plan.MaxBytesPerMinute
+	}
+	if s.inherits("shaping", !s.Shaping) {
+		s.Shaping = plan.Shaping
+	}
+	if s.inherits("max_delay", s.MaxDelay == "") {
+		s.MaxDelay = plan.MaxDelay
+	}
+	if s.inherits("max_queue", s.MaxQueue == 0) {
+		s.MaxQueue = plan.MaxQueue
+	}
+	if s.inherits("timezone", s.Timezone == "") {
+		s.Timezone = plan.Timezone
+	}
+	if s.inherits("schedules", len(s.Schedules) == 0) {
+		s.Schedules = plan.Schedules
+	}
+	if s.inherits("quotas", len(s.Quotas) == 0) {
+		s.Quotas = plan.Quotas
+	}
+	if s.inherits("adaptive", !s.Adaptive.Enabled) {
+		s.Adaptive = plan.Adaptive
+	}
+	if s.inherits("priority", s.Priority == 0) {
+		s.Priority = plan.Priority
+	}
+	if s.inherits("mode", s.Mode == "") {
+		s.Mode = plan.Mode
+	}
+	if s.inherits("language", s.Language == "") {
+		s.Language = plan.Language
+	}
+	if s.inherits("messages", len(s.Messages) == 0) {
+		s.Messages = plan.Messages
+	}
+	if s.inherits("error_format", s.ErrorFormat == "") {
+		s.ErrorFormat = plan.ErrorFormat
+	}
+	return nil
+}
+
+// inherits reports whether the service takes field from its plan: when the
+// services file does not set it, or, for configs built in code without
+// SetFields, when the service's value is zero
+func (s *ServiceConfig) inherits(field string, zero bool) bool {
+	if s.SetFields != nil {
+		return !s.SetFields[field]
+	}
+	return zero
+}
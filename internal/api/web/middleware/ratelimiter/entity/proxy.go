@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DefaultRequesterHeader carries the resolved service name to the upstream
+const DefaultRequesterHeader = "X-Ratelimit-Requester"
+
+// ProxyConfig turns the binary into a reverse proxy that rate limits requests
+// before forwarding them to the upstream matching the longest route prefix
+type ProxyConfig struct {
+	Enabled         bool             `mapstructure:"enabled"`
+	RequesterHeader string           `mapstructure:"requester_header"`
+	Upstreams       []UpstreamConfig `mapstructure:"upstreams"`
+}
+
+type UpstreamConfig struct {
+	Prefix      string `mapstructure:"prefix"`
+	URL         string `mapstructure:"url"`
+	StripPrefix bool   `mapstructure:"strip_prefix"`
+}
+
+func (p *ProxyConfig) Validate() error {
+	if !p.Enabled {
+		return nil
+	}
+
+	if p.RequesterHeader == "" {
+		p.RequesterHeader = DefaultRequesterHeader
+	}
+
+	if len(p.Upstreams) == 0 {
+		return errors.New("proxy is enabled but no upstreams are configured")
+	}
+
+	seenPrefixes := make(map[string]bool)
+	for i := range p.Upstreams {
+		u := &p.Upstreams[i]
+		if u.Prefix == "" {
+			u.Prefix = "/"
+		}
+		if !strings.HasPrefix(u.Prefix, "/") {
+			return fmt.Errorf("upstream prefix '%s' must start with '/'", u.Prefix)
+		}
+		if seenPrefixes[u.Prefix] {
+			return fmt.Errorf("duplicate upstream prefix '%s'", u.Prefix)
+		}
+		seenPrefixes[u.Prefix] = true
+
+		parsed, err := url.Parse(u.URL)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("invalid url for upstream '%s': %q", u.Prefix, u.URL)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// Quota periods
+const (
+	QuotaDay   = "day"
+	QuotaWeek  = "week"
+	QuotaMonth = "month"
+)
+
+// QuotaConfig caps the requests of a calendar period (e.g. 1M per month),
+// resetting at the start of the day, week (Monday) or month in the service's
+// timezone rather than on a rolling window
+type QuotaConfig struct {
+	Period string `mapstructure:"period"`
+	Limit  int64  `mapstructure:"limit"`
+}
+
+// Bounds returns the start and the end of the period containing now
+func (q QuotaConfig) Bounds(now time.Time, loc *time.Location) (time.Time, time.Time) {
+	local := now.In(loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	switch q.Period {
+	case QuotaWeek:
+		start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return start, start.AddDate(0, 0, 7)
+	case QuotaMonth:
+		start := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 1, 0)
+	default:
+		return day, day.AddDate(0, 0, 1)
+	}
+}
+
+// PeriodKey identifies the period containing now, e.g. "month:2025-06"
+func (q QuotaConfig) PeriodKey(now time.Time, loc *time.Location) string {
+	start, _ := q.Bounds(now, loc)
+	if q.Period == QuotaMonth {
+		return fmt.Sprintf("%s:%s", q.Period, start.Format("2006-01"))
+	}
+	return fmt.Sprintf("%s:%s", q.Period, start.Format("2006-01-02"))
+}
+
+func validateQuotas(quotas []QuotaConfig) error {
+	seen := make(map[string]bool)
+	for _, q := range quotas {
+		if q.Period != QuotaDay && q.Period != QuotaWeek && q.Period != QuotaMonth {
+			return fmt.Errorf("invalid quota period '%s': must be 'day', 'week' or 'month'", q.Period)
+		}
+		if seen[q.Period] {
+			return fmt.Errorf("duplicate quota period '%s'", q.Period)
+		}
+		seen[q.Period] = true
+		if q.Limit < 1 {
+			return fmt.Errorf("limit must be >= 1 for quota '%s'", q.Period)
+		}
+	}
+	return nil
+}
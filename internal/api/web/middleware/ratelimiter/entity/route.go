@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"strings"
+)
+
+// RouteRule sets how much quota requests to a route consume. Bulk endpoints can
+// cost e.g. 100 so they drain the limit as fast as 100 single lookups.
+type RouteRule struct {
+	Method string `mapstructure:"method"`
+	Prefix string `mapstructure:"prefix"`
+	// Cost defaults to 1, for rules that only set Mode
+	Cost int `mapstructure:"cost"`
+	// Mode overrides the service's mode for matching requests
+	Mode string `mapstructure:"mode"`
+}
+
+// Matches reports whether the rule applies to the request. An empty method
+// matches any method.
+func (r RouteRule) Matches(method, path string) bool {
+	if r.Method != "" && !strings.EqualFold(r.Method, method) {
+		return false
+	}
+	return MatchesPathPrefix(path, r.Prefix)
+}
+
+// MatchesPathPrefix only matches on path segment boundaries, so "/api" does not catch "/apix"
+func MatchesPathPrefix(path, prefix string) bool {
+	if prefix == "/" || path == prefix {
+		return true
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	return strings.HasPrefix(path, prefix+"/")
+}
+
+// RouteFor returns the most specific matching rule (longest prefix, then a
+// rule with a method over one without), or nil when no rule matches
+func (s ServiceConfig) RouteFor(method, path string) *RouteRule {
+	var best *RouteRule
+	for i := range s.Routes {
+		r := &s.Routes[i]
+		if !r.Matches(method, path) {
+			continue
+		}
+		if best == nil || len(r.Prefix) > len(best.Prefix) ||
+			(len(r.Prefix) == len(best.Prefix) && best.Method == "" && r.Method != "") {
+			best = r
+		}
+	}
+	return best
+}
+
+// CostFor returns the cost of the matching rule, or 1 when no rule matches or
+// the rule sets no cost
+func (s ServiceConfig) CostFor(method, path string) int {
+	if r := s.RouteFor(method, path); r != nil && r.Cost > 0 {
+		return r.Cost
+	}
+	return 1
+}
+
+// ShadowFor reports whether limits run in shadow mode for the request
+func (s ServiceConfig) ShadowFor(method, path string) bool {
+	if r := s.RouteFor(method, path); r != nil && r.Mode != "" {
+		return r.Mode == ModeShadow
+	}
+	return s.Mode == ModeShadow
+}
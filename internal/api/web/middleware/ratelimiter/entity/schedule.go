@@ -0,0 +1,146 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ScheduleEntry overrides the service's limit during a weekly time range, e.g.
+// a higher allowed_rps at night. From and To are "HH:MM" in the service's
+// timezone; a range whose To is not after From ends on the next day, and equal
+// From and To cover the whole day. Days lists the days the range starts on
+// ("mon", "tue", ...); empty means every day.
+type ScheduleEntry struct {
+	Name       string   `mapstructure:"name"`
+	Days       []string `mapstructure:"days"`
+	From       string   `mapstructure:"from"`
+	To         string   `mapstructure:"to"`
+	AllowedRPS int      `mapstructure:"allowed_rps"`
+	// Window overrides the counting window (e.g. "1m"); when empty it follows
+	// allowed_rps like the service's own window
+	Window string `mapstructure:"window"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// locations caches loaded timezones, as time.LoadLocation reads them from disk
+var locations sync.Map
+
+// Location returns the service's timezone, UTC when unset or invalid
+func (s ServiceConfig) Location() *time.Location {
+	if s.Timezone == "" {
+		return time.UTC
+	}
+	if loc, ok := locations.Load(s.Timezone); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	locations.Store(s.Timezone, loc)
+	return loc
+}
+
+// ActiveSchedule returns the first schedule entry covering now, or nil
+func (s ServiceConfig) ActiveSchedule(now time.Time) *ScheduleEntry {
+	if len(s.Schedules) == 0 {
+		return nil
+	}
+	local := now.In(s.Location())
+	for i := range s.Schedules {
+		if s.Schedules[i].covers(local) {
+			return &s.Schedules[i]
+		}
+	}
+	return nil
+}
+
+// WindowSeconds returns the size of the counting window of the entry
+func (e ScheduleEntry) WindowSeconds() int64 {
+	if d, err := time.ParseDuration(e.Window); err == nil && d >= time.Second {
+		return int64(d / time.Second)
+	}
+	return int64(e.AllowedRPS)
+}
+
+func (e ScheduleEntry) covers(local time.Time) bool {
+	from, _ := minuteOfDay(e.From)
+	to, _ := minuteOfDay(e.To)
+	minute := local.Hour()*60 + local.Minute()
+
+	switch {
+	case from < to:
+		return minute >= from && minute < to && e.startsOn(local.Weekday())
+	case minute >= from:
+		return e.startsOn(local.Weekday())
+	case minute < to:
+		// Faixa iniciada no dia anterior que atravessa a meia-noite
+		return e.startsOn((local.Weekday() + 6) % 7)
+	}
+	return false
+}
+
+func (e ScheduleEntry) startsOn(day time.Weekday) bool {
+	if len(e.Days) == 0 {
+		return true
+	}
+	for _, d := range e.Days {
+		if weekdays[strings.ToLower(d)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+func minuteOfDay(hhmm string) (int, error) {
+	t, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func validateSchedules(timezone string, schedules []ScheduleEntry) error {
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return fmt.Errorf("unknown timezone '%s'", timezone)
+		}
+	}
+	for _, e := range schedules {
+		if e.Name == "" {
+			return errors.New("schedule name cannot be empty")
+		}
+		if _, err := minuteOfDay(e.From); err != nil {
+			return fmt.Errorf("from of schedule '%s' must be HH:MM", e.Name)
+		}
+		if _, err := minuteOfDay(e.To); err != nil {
+			return fmt.Errorf("to of schedule '%s' must be HH:MM", e.Name)
+		}
+		for _, d := range e.Days {
+			if _, ok := weekdays[strings.ToLower(d)]; !ok {
+				return fmt.Errorf("invalid day '%s' in schedule '%s'", d, e.Name)
+			}
+		}
+		if e.AllowedRPS < 1 {
+			return fmt.Errorf("allowed_rps must be >= 1 for schedule '%s'", e.Name)
+		}
+		if e.Window != "" {
+			if d, err := time.ParseDuration(e.Window); err != nil || d < time.Second {
+				return fmt.Errorf("window of schedule '%s' must be a duration of at least 1s", e.Name)
+			}
+		}
+	}
+	return nil
+}
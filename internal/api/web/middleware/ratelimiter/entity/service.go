@@ -3,6 +3,10 @@ package entity
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/messages"
 )
 
 type ServiceConfig struct {
@@ -13,10 +17,168 @@ type ServiceConfig struct {
 	Valid                   bool   `mapstructure:"valid"`
 	AllowedRPS              int    `mapstructure:"allowed_rps"`
 	WaitTimeIfLimitExceeded string `mapstructure:"wait_time_if_limit_exceeded"`
+
+	// Plan names an entry of the plans section whose limits the service
+	// inherits. Any limit set on the service itself overrides the plan's.
+	Plan string `mapstructure:"plan"`
+	// SetFields holds the keys the services file sets on the service, filled
+	// in by configs.LoadConfig, so that explicit zero values override the plan
+	SetFields map[string]bool `mapstructure:"-" json:"-"`
+
+	// Group names an entry of the groups section (e.g. the customer's
+	// organization) whose quota is shared by all of its services, so minting
+	// more keys does not raise the limit. GroupAllowedRPS is filled in from the
+	// group by Validate.
+	Group           string `mapstructure:"group"`
+	GroupAllowedRPS int    `mapstructure:"-"`
+
+	// GlobalAllowedRPS is the instance-wide limit the service is held to and
+	// GlobalBypass exempts it from being blocked by it; both are filled in from
+	// the global section by GlobalConfig.Apply
+	GlobalAllowedRPS int  `mapstructure:"-"`
+	GlobalBypass     bool `mapstructure:"-"`
+
+	// LocalSyncInterval enables approximate counting for very high throughput keys
+	// (e.g. "100ms"). Each instance counts locally and flushes its deltas to Redis
+	// once per interval, deciding on the last known global count plus its own
+	// unflushed requests. The first request of each window is counted in Redis
+	// right away, but traffic from other instances is only seen after their next
+	// flush, so with N instances the limit can be overshot by up to N-1
+	// intervals' worth of requests. Leave empty to count every request in Redis.
+	LocalSyncInterval string `mapstructure:"local_sync_interval"`
+
+	// MaxConcurrent caps the requests of this service being processed at the same
+	// time (0 = unlimited). Each request holds a lease of ConcurrencyLeaseTTL
+	// (default "30s") that is renewed while it runs and reclaimed if the instance dies.
+	MaxConcurrent       int    `mapstructure:"max_concurrent"`
+	ConcurrencyLeaseTTL string `mapstructure:"concurrency_lease_ttl"`
+
+	// Routes sets the cost of matching requests; unmatched requests cost 1.
+	// Services without routes inherit the ones of the default service.
+	Routes []RouteRule `mapstructure:"routes"`
+
+	// Post-response accounting: quota is reserved before the handler runs and
+	// settled once the response is known. RefundServerErrors gives back the cost
+	// of 5xx responses; MaxBytesPerMinute caps the body bytes served (0 = off).
+	RefundServerErrors bool  `mapstructure:"refund_server_errors"`
+	MaxBytesPerMinute  int64 `mapstructure:"max_bytes_per_minute"`
+
+	// Shaping slows callers down instead of rejecting them. Requests are spaced
+	// 1/allowed_rps apart leaky-bucket style, with bursts of up to allowed_rps
+	// admitted immediately. A request over the limit waits for its slot when it
+	// is at most MaxDelay away and fewer than MaxQueue (0 = unbounded) requests
+	// of the service are already waiting; otherwise it gets a 429.
+	Shaping  bool   `mapstructure:"shaping"`
+	MaxDelay string `mapstructure:"max_delay"`
+	MaxQueue int    `mapstructure:"max_queue"`
+
+	// Schedules override allowed_rps and the counting window during weekly time
+	// ranges, evaluated in Timezone (an IANA name, default UTC). The first entry
+	// covering the current time wins.
+	Timezone  string          `mapstructure:"timezone"`
+	Schedules []ScheduleEntry `mapstructure:"schedules"`
+
+	// Quotas cap the requests per calendar day, week or month, reset at the
+	// start of the period in Timezone and kept in the Store until it ends
+	Quotas []QuotaConfig `mapstructure:"quotas"`
+
+	// Adaptive replaces the static allowed_rps by a limit driven by the
+	// latency and errors of the backend. See AdaptiveConfig.
+	Adaptive AdaptiveConfig `mapstructure:"adaptive"`
+
+	// Priority ranks the service when the instance sheds load: lower priorities
+	// (e.g. 0 for the free tier) are rejected first. See SheddingConfig.
+	Priority int `mapstructure:"priority"`
+
+	// Mode "shadow" computes counters and decisions as usual but lets requests
+	// that would be blocked through, recording each would-be block instead.
+	// Route rules can set their own mode, which wins over the service's.
+	Mode string `mapstructure:"mode"`
+
+	// Language is the language of the messages sent to callers whose
+	// Accept-Language matches no catalog language (default: the top-level
+	// language, then pt-BR). Messages overrides the built-in templates by
+	// language and code, e.g. messages.en.rate_limited.
+	Language string             `mapstructure:"language"`
+	Messages messages.Overrides `mapstructure:"messages"`
+
+	// ErrorFormat is "problem" (default, RFC 9457) or "legacy", inherited from
+	// the top-level error_format. ProblemTypeBase is filled in from the
+	// top-level problem_type_base by Validate.
+	ErrorFormat     string `mapstructure:"error_format"`
+	ProblemTypeBase string `mapstructure:"-"`
+
+	// Unregistered is set on the copy of the default service a Store hands out
+	// for an unknown key, which gets a generated Name
+	Unregistered bool `mapstructure:"-"`
+}
+
+// Limit modes. An empty mode enforces.
+const (
+	ModeEnforce = "enforce"
+	ModeShadow  = "shadow"
+)
+
+func validMode(mode string) bool {
+	return mode == "" || mode == ModeEnforce || mode == ModeShadow
+}
+
+// DefaultServiceName names the service applied to unknown IPs and keys
+const DefaultServiceName = "default"
+
+// ConfiguredName returns the name of the services file entry the config comes
+// from: DefaultServiceName for unregistered keys. Unlike Name, it only takes
+// the values in the file, so it is safe to use as a metric label.
+func (s ServiceConfig) ConfiguredName() string {
+	if s.Unregistered {
+		return DefaultServiceName
+	}
+	return s.Name
+}
+
+// ShapingMaxDelay returns the parsed MaxDelay, or 0 when it is not set
+func (s ServiceConfig) ShapingMaxDelay() time.Duration {
+	d, err := time.ParseDuration(s.MaxDelay)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// DefaultConcurrencyLeaseTTL applies when ConcurrencyLeaseTTL is empty
+const DefaultConcurrencyLeaseTTL = 30 * time.Second
+
+// LeaseTTL returns the parsed ConcurrencyLeaseTTL, falling back to the default
+func (s ServiceConfig) LeaseTTL() time.Duration {
+	d, err := time.ParseDuration(s.ConcurrencyLeaseTTL)
+	if err != nil || d <= 0 {
+		return DefaultConcurrencyLeaseTTL
+	}
+	return d
+}
+
+// SyncInterval returns the parsed LocalSyncInterval, or 0 when local counting is disabled
+func (s ServiceConfig) SyncInterval() time.Duration {
+	d, err := time.ParseDuration(s.LocalSyncInterval)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
 }
 
 type Config struct {
 	Services []*ServiceConfig `mapstructure:"services"`
+	Plans    Plans            `mapstructure:"plans"`
+	Groups   Groups           `mapstructure:"groups"`
+	Global   GlobalConfig     `mapstructure:"global"`
+	Shedding SheddingConfig   `mapstructure:"shedding"`
+	Proxy    ProxyConfig      `mapstructure:"proxy"`
+	// Language and ErrorFormat are inherited by services that do not set their own
+	Language    string `mapstructure:"language"`
+	ErrorFormat string `mapstructure:"error_format"`
+	// ProblemTypeBase prefixes the error code in the "type" of problem+json
+	// bodies, e.g. "https://docs.example.com/problems/"
+	ProblemTypeBase string `mapstructure:"problem_type_base"`
 }
 
 func (c *Config) Validate() []error {
@@ -29,17 +191,28 @@ func (c *Config) Validate() []error {
 		return append(Errors, errors.New("services cannot be empty"))
 	}
 
+	// Resolve plans before anything else, so plan limits are validated and
+	// take precedence over the ones inherited from default
+	planErrors := make(map[*ServiceConfig]error)
+	for _, s := range c.Services {
+		if err := c.Plans.applyPlan(s); err != nil {
+			planErrors[s] = err
+		}
+	}
+
 	// Check if default service is missing
 	var defaultWaitTime string
 	var defaultAllowedRPS int
+	var defaultRoutes []RouteRule
 	var hasDefault bool
 
 	for _, s := range c.Services {
-		if s.Name == "default" {
+		if s.Name == DefaultServiceName {
 			defaultWaitTime = s.WaitTimeIfLimitExceeded
 			defaultAllowedRPS = s.AllowedRPS
+			defaultRoutes = s.Routes
 			hasDefault = true
-			s.Key = "default"
+			s.Key = DefaultServiceName
 		}
 	}
 	if !hasDefault {
@@ -59,6 +232,16 @@ func (c *Config) Validate() []error {
 			continue
 		}
 
+		if err, ok := planErrors[s]; ok {
+			Errors = append(Errors, err)
+			continue
+		}
+
+		if err := c.Groups.applyGroup(s); err != nil {
+			Errors = append(Errors, err)
+			continue
+		}
+
 		if s.Type != "ip" && s.Type != "token" {
 			Errors = append(Errors, fmt.Errorf("invalid type for service '%s': must be 'ip' or 'token'", s.Name))
 			continue
@@ -69,6 +252,97 @@ func (c *Config) Validate() []error {
 			continue
 		}
 
+		if s.LocalSyncInterval != "" {
+			if d, err := time.ParseDuration(s.LocalSyncInterval); err != nil || d <= 0 {
+				Errors = append(Errors, fmt.Errorf("local_sync_interval must be a positive duration for service '%s'", s.Name))
+				continue
+			}
+		}
+
+		if s.Shaping {
+			if d, err := time.ParseDuration(s.MaxDelay); err != nil || d <= 0 {
+				Errors = append(Errors, fmt.Errorf("max_delay must be a positive duration for shaped service '%s'", s.Name))
+				continue
+			}
+			if s.MaxQueue < 0 {
+				Errors = append(Errors, fmt.Errorf("max_queue must be >= 0 for service '%s'", s.Name))
+				continue
+			}
+		}
+
+		if err := validateSchedules(s.Timezone, s.Schedules); err != nil {
+			Errors = append(Errors, fmt.Errorf("invalid schedules for service '%s': %w", s.Name, err))
+			continue
+		}
+
+		if err := validateQuotas(s.Quotas); err != nil {
+			Errors = append(Errors, fmt.Errorf("invalid quotas for service '%s': %w", s.Name, err))
+			continue
+		}
+
+		if err := s.Adaptive.validate(); err != nil {
+			Errors = append(Errors, fmt.Errorf("invalid adaptive config for service '%s': %w", s.Name, err))
+			continue
+		}
+
+		if s.Priority < 0 {
+			Errors = append(Errors, fmt.Errorf("priority must be >= 0 for service '%s'", s.Name))
+			continue
+		}
+
+		if !validMode(s.Mode) {
+			Errors = append(Errors, fmt.Errorf("invalid mode for service '%s': must be 'enforce' or 'shadow'", s.Name))
+			continue
+		}
+
+		if s.Language == "" {
+			s.Language = c.Language
+		}
+		if s.Language != "" {
+			lang, ok := messages.Supported(s.Language)
+			if !ok {
+				Errors = append(Errors, fmt.Errorf("unsupported language '%s' for service '%s'", s.Language, s.Name))
+				continue
+			}
+			s.Language = lang
+		}
+
+		if err := s.Messages.Validate(); err != nil {
+			Errors = append(Errors, fmt.Errorf("invalid messages for service '%s': %w", s.Name, err))
+			continue
+		}
+
+		if s.ErrorFormat == "" {
+			s.ErrorFormat = c.ErrorFormat
+		}
+		if !validErrorFormat(s.ErrorFormat) {
+			Errors = append(Errors, fmt.Errorf("invalid error_format for service '%s': must be 'problem' or 'legacy'", s.Name))
+			continue
+		}
+		s.ProblemTypeBase = c.ProblemTypeBase
+
+		if s.MaxBytesPerMinute < 0 {
+			Errors = append(Errors, fmt.Errorf("max_bytes_per_minute must be >= 0 for service '%s'", s.Name))
+			continue
+		}
+
+		if s.MaxConcurrent < 0 {
+			Errors = append(Errors, fmt.Errorf("max_concurrent must be >= 0 for service '%s'", s.Name))
+			continue
+		}
+
+		if s.ConcurrencyLeaseTTL != "" {
+			if d, err := time.ParseDuration(s.ConcurrencyLeaseTTL); err != nil || d <= 0 {
+				Errors = append(Errors, fmt.Errorf("concurrency_lease_ttl must be a positive duration for service '%s'", s.Name))
+				continue
+			}
+		}
+
+		if err := validateRoutes(s.Routes); err != nil {
+			Errors = append(Errors, fmt.Errorf("invalid routes for service '%s': %w", s.Name, err))
+			continue
+		}
+
 		if s.Type == "token" && s.Key == "" {
 			Errors = append(Errors, fmt.Errorf("key cannot be empty for service '%s' of type 'token'", s.Name))
 			continue
@@ -84,16 +358,42 @@ func (c *Config) Validate() []error {
 		ValidServices = append(ValidServices, s)
 	}
 
-	// Set defaults for missing config
+	// Set defaults for missing config; zero values set in the services file are kept
 	for _, vs := range ValidServices {
-		if vs.WaitTimeIfLimitExceeded == "" && vs.Valid {
+		if !vs.Valid {
+			continue
+		}
+		if vs.WaitTimeIfLimitExceeded == "" && !vs.SetFields["wait_time_if_limit_exceeded"] {
 			vs.WaitTimeIfLimitExceeded = defaultWaitTime
 		}
-		if vs.AllowedRPS == 0 && vs.Valid {
+		if vs.AllowedRPS == 0 && !vs.SetFields["allowed_rps"] {
 			vs.AllowedRPS = defaultAllowedRPS
 		}
+		if len(vs.Routes) == 0 && !vs.SetFields["routes"] {
+			vs.Routes = defaultRoutes
+		}
 	}
 
 	c.Services = ValidServices
 	return Errors
 }
+
+func validateRoutes(routes []RouteRule) error {
+	for i := range routes {
+		r := &routes[i]
+		if !strings.HasPrefix(r.Prefix, "/") {
+			return fmt.Errorf("route prefix '%s' must start with '/'", r.Prefix)
+		}
+		// Regras que só mudam o modo mantêm o custo padrão
+		if r.Cost == 0 {
+			r.Cost = 1
+		}
+		if r.Cost < 1 {
+			return fmt.Errorf("cost must be >= 1 for route '%s'", r.Prefix)
+		}
+		if !validMode(r.Mode) {
+			return fmt.Errorf("mode of route '%s' must be 'enforce' or 'shadow'", r.Prefix)
+		}
+	}
+	return nil
+}
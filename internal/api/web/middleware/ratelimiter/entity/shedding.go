@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// Shedding defaults
+const (
+	DefaultShedFrom       = 0.8
+	DefaultPriorityStep   = 0.1
+	DefaultShedRetryAfter = time.Second
+)
+
+// SheddingConfig drops low priority traffic first when the instance nears its
+// capacity. Load is the highest of in-flight requests over MaxInFlight and
+// requests admitted in the current second over MaxRPS (either may be 0 to
+// ignore it). Priority p is shed once load reaches ShedFrom + p*PriorityStep,
+// capped at 1, so with the defaults priority 0 goes at 80% and priority 2 or
+// higher only at full capacity. ShedFrom is a pointer so that an explicit 0
+// (shed priority 0 at any load) is told apart from an unset value.
+type SheddingConfig struct {
+	MaxInFlight  int      `mapstructure:"max_in_flight"`
+	MaxRPS       int      `mapstructure:"max_rps"`
+	ShedFrom     *float64 `mapstructure:"shed_from"`
+	PriorityStep float64  `mapstructure:"priority_step"`
+	RetryAfter   string   `mapstructure:"retry_after"`
+}
+
+// Enabled reports whether a capacity signal is configured
+func (s SheddingConfig) Enabled() bool {
+	return s.MaxInFlight > 0 || s.MaxRPS > 0
+}
+
+// Threshold returns the load at which requests of priority start being shed
+func (s SheddingConfig) Threshold(priority int) float64 {
+	shedFrom := DefaultShedFrom
+	if s.ShedFrom != nil {
+		shedFrom = *s.ShedFrom
+	}
+	step := s.PriorityStep
+	if step == 0 {
+		step = DefaultPriorityStep
+	}
+	return min(shedFrom+float64(priority)*step, 1)
+}
+
+// RetryAfterDuration returns the parsed RetryAfter, falling back to the default
+func (s SheddingConfig) RetryAfterDuration() time.Duration {
+	d, err := time.ParseDuration(s.RetryAfter)
+	if err != nil || d <= 0 {
+		return DefaultShedRetryAfter
+	}
+	return d
+}
+
+func (s *SheddingConfig) Validate() error {
+	if s.MaxInFlight < 0 || s.MaxRPS < 0 {
+		return errors.New("shedding max_in_flight and max_rps must be >= 0")
+	}
+	if s.ShedFrom != nil && (*s.ShedFrom < 0 || *s.ShedFrom > 1) {
+		return errors.New("shedding shed_from must be between 0 and 1")
+	}
+	if s.PriorityStep < 0 {
+		return errors.New("shedding priority_step must be >= 0")
+	}
+	if s.RetryAfter != "" {
+		if d, err := time.ParseDuration(s.RetryAfter); err != nil || d <= 0 {
+			return errors.New("shedding retry_after must be a positive duration")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// UsageRecord holds how many requests of a service were admitted and rejected
+// during the hour starting at Hour (UTC)
+type UsageRecord struct {
+	Hour     time.Time `json:"hour"`
+	Service  string    `json:"service"`
+	Admitted int64     `json:"admitted"`
+	Rejected int64     `json:"rejected"`
+}
+
+// UsageHour truncates t to the UTC hour its usage is aggregated in
+func UsageHour(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Hour)
+}
@@ -0,0 +1,196 @@
+package localcounter
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/repository"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/infra/logging"
+)
+
+// Counter counts request costs in memory and periodically flushes the deltas to
+// the Store with a single increment per counter. Between flushes the count it
+// reports is the last known global count plus the costs seen locally, so other
+// instances' traffic is only visible after their next flush. The first request
+// of each window goes straight to the Store, so the estimate starts from the
+// real global count instead of 0.
+type Counter struct {
+	store  repository.Store
+	now    func() time.Time
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	entries map[string]*entry
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type entry struct {
+	key       string
+	windowKey string
+	interval  time.Duration
+	ttl       time.Duration
+	pending   int
+	global    int
+	lastFlush time.Time
+	lastSeen  time.Time
+}
+
+// NewCounter builds a Counter flushing to store. A nil logger logs to slog.Default().
+func NewCounter(store repository.Store, now func() time.Time, logger *slog.Logger) *Counter {
+	return &Counter{
+		store:   store,
+		now:     now,
+		logger:  logging.OrDefault(logger),
+		entries: make(map[string]*entry),
+	}
+}
+
+// Increment records a request costing amount for key in windowKey and returns
+// the estimated global count. interval is how often this counter is flushed and
+// ttl is the expiration applied to the Redis counter when it is created.
+func (c *Counter) Increment(key string, windowKey string, amount int, interval time.Duration, ttl time.Duration) int {
+	c.mu.Lock()
+	now := c.now()
+	id := fmt.Sprintf("%s:%s", key, windowKey)
+	e, ok := c.entries[id]
+	if !ok {
+		e = &entry{
+			key:       key,
+			windowKey: windowKey,
+			interval:  interval,
+			ttl:       ttl,
+			lastFlush: now,
+		}
+		c.entries[id] = e
+	}
+	e.lastSeen = now
+
+	if ok || amount <= 0 {
+		e.pending += amount
+		defer c.mu.Unlock()
+		return e.global + e.pending
+	}
+	c.mu.Unlock()
+
+	// Primeira requisição da janela nesta instância: sincroniza já, para que a
+	// estimativa inclua o que as outras instâncias contaram antes dela
+	count, err := c.store.IncrementRequestCount(key, windowKey, amount)
+
+	c.mu.Lock()
+	if err != nil {
+		// Fica pendente e é reenviado no próximo flush
+		e.pending += amount
+	} else {
+		// Um flush concorrente pode já ter trazido uma contagem mais recente
+		e.global = max(e.global, count)
+	}
+	estimate := e.global + e.pending
+	c.mu.Unlock()
+
+	// Counter was created by this request
+	if err == nil && count == amount {
+		_ = c.store.SetExpiration(key, windowKey, ttl)
+	}
+	return estimate
+}
+
+// FlushDue pushes the pending deltas of every counter whose interval has elapsed
+func (c *Counter) FlushDue() error {
+	return c.flush(false)
+}
+
+// FlushAll pushes every pending delta regardless of its interval
+func (c *Counter) FlushAll() error {
+	return c.flush(true)
+}
+
+func (c *Counter) flush(force bool) error {
+	type batch struct {
+		e     *entry
+		delta int
+	}
+
+	// Take the deltas under lock, then talk to the store without holding it
+	c.mu.Lock()
+	now := c.now()
+	var batches []batch
+	for id, e := range c.entries {
+		if !force && now.Sub(e.lastFlush) < e.interval {
+			continue
+		}
+		e.lastFlush = now
+		if e.pending == 0 {
+			// Forget counters whose window has long gone quiet
+			if now.Sub(e.lastSeen) > e.ttl {
+				delete(c.entries, id)
+			}
+			continue
+		}
+		batches = append(batches, batch{e: e, delta: e.pending})
+		e.pending = 0
+	}
+	c.mu.Unlock()
+
+	var firstErr error
+	for _, b := range batches {
+		count, err := c.store.IncrementRequestCount(b.e.key, b.e.windowKey, b.delta)
+
+		c.mu.Lock()
+		if err != nil {
+			// Keep the delta so it is retried on the next flush
+			b.e.pending += b.delta
+		} else {
+			b.e.global = count
+		}
+		c.mu.Unlock()
+
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		// Counter was created by this flush
+		if count == b.delta {
+			_ = c.store.SetExpiration(b.e.key, b.e.windowKey, b.e.ttl)
+		}
+	}
+
+	return firstErr
+}
+
+// Start flushes due counters every tick until Close is called
+func (c *Counter) Start(tick time.Duration) {
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.FlushDue(); err != nil {
+					c.logger.Warn("falha ao sincronizar contadores locais", "error", err)
+				}
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the flush loop and pushes whatever is still pending
+func (c *Counter) Close() error {
+	if c.stop != nil {
+		close(c.stop)
+		<-c.done
+	}
+	return c.FlushAll()
+}
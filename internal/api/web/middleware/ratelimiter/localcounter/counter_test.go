@@ -0,0 +1,140 @@
+package localcounter_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/localcounter"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStore keeps counters in memory and records every increment it receives.
+// Methods the Counter never calls are left to the embedded nil interface.
+type fakeStore struct {
+	repository.Store
+	counters map[string]int
+	ttls     map[string]time.Duration
+	incrBys  int
+	fail     bool
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{counters: map[string]int{}, ttls: map[string]time.Duration{}}
+}
+
+func (f *fakeStore) IncrementRequestCount(key string, windowKey string, delta int) (int, error) {
+	if f.fail {
+		return 0, errors.New("store unavailable")
+	}
+	f.incrBys++
+	f.counters[key+":"+windowKey] += delta
+	return f.counters[key+":"+windowKey], nil
+}
+func (f *fakeStore) SetExpiration(key string, windowKey string, ttl time.Duration) error {
+	f.ttls[key+":"+windowKey] = ttl
+	return nil
+}
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func TestCounter_CountsLocallyUntilIntervalElapses(t *testing.T) {
+	// Arrange
+	store := newFakeStore()
+	clk := &clock{t: time.Unix(1000, 0)}
+	counter := localcounter.NewCounter(store, clk.now, nil)
+
+	// Act
+	for i := 0; i < 5; i++ {
+		counter.Increment("abcd1234", "1", 1, 100*time.Millisecond, time.Minute)
+	}
+	clk.t = clk.t.Add(50 * time.Millisecond)
+	errEarly := counter.FlushDue()
+	incrBysBeforeInterval := store.incrBys
+
+	clk.t = clk.t.Add(50 * time.Millisecond)
+	errDue := counter.FlushDue()
+
+	// Assert
+	assert.Nil(t, errEarly)
+	assert.Nil(t, errDue)
+	// Only the first request of the window went to the store
+	assert.Equal(t, 1, incrBysBeforeInterval)
+	assert.Equal(t, 2, store.incrBys)
+	assert.Equal(t, 5, store.counters["abcd1234:1"])
+	assert.Equal(t, time.Minute, store.ttls["abcd1234:1"])
+}
+
+func TestCounter_EstimateUsesLastKnownGlobalCount(t *testing.T) {
+	// Arrange
+	store := newFakeStore()
+	clk := &clock{t: time.Unix(1000, 0)}
+	counter := localcounter.NewCounter(store, clk.now, nil)
+
+	// Another instance already flushed 10 requests for this window
+	store.counters["abcd1234:1"] = 10
+
+	// Act
+	first := counter.Increment("abcd1234", "1", 1, 100*time.Millisecond, time.Minute)
+	clk.t = clk.t.Add(100 * time.Millisecond)
+	_ = counter.FlushDue()
+	second := counter.Increment("abcd1234", "1", 1, 100*time.Millisecond, time.Minute)
+
+	// Assert
+	assert.Equal(t, 11, first)
+	assert.Equal(t, 12, second)
+	// The counter existed already, so the TTL is not reapplied
+	assert.NotContains(t, store.ttls, "abcd1234:1")
+}
+
+func TestCounter_KeepsDeltaWhenFlushFails(t *testing.T) {
+	// Arrange
+	store := newFakeStore()
+	clk := &clock{t: time.Unix(1000, 0)}
+	counter := localcounter.NewCounter(store, clk.now, nil)
+	counter.Increment("abcd1234", "1", 1, 100*time.Millisecond, time.Minute)
+	counter.Increment("abcd1234", "1", 1, 100*time.Millisecond, time.Minute)
+
+	// Act
+	store.fail = true
+	errFailed := counter.FlushAll()
+	store.fail = false
+	errRetried := counter.Close()
+
+	// Assert
+	assert.NotNil(t, errFailed)
+	assert.Nil(t, errRetried)
+	assert.Equal(t, 2, store.counters["abcd1234:1"])
+}
+
+func TestCounter_NewWindowStartsFromTheSyncedGlobalCount(t *testing.T) {
+	// Arrange
+	const limit = 10
+	store := newFakeStore()
+	clk := &clock{t: time.Unix(1000, 0)}
+	a := localcounter.NewCounter(store, clk.now, nil)
+	b := localcounter.NewCounter(store, clk.now, nil)
+	allowed := func(c *localcounter.Counter) int {
+		n := 0
+		for c.Increment("abcd1234", "1", 1, 100*time.Millisecond, time.Minute) <= limit {
+			n++
+		}
+		return n
+	}
+
+	// Act
+	// Instance a uses up the window and syncs before b sees its first request
+	allowedA := allowed(a)
+	clk.t = clk.t.Add(100 * time.Millisecond)
+	_ = a.FlushDue()
+	allowedB := allowed(b)
+
+	// Assert
+	assert.Equal(t, limit, allowedA)
+	// b starts from the synced count instead of 0, so nothing is overshot
+	assert.Equal(t, 0, allowedB)
+}
@@ -0,0 +1,66 @@
+package messages
+
+// Stable, machine-readable codes of every refusal. They are sent to clients
+// alongside the message and never change with the language.
+const (
+	ConfigError              = "config_error"
+	ServiceBlocked           = "service_blocked"
+	Overloaded               = "overloaded"
+	CounterError             = "counter_error"
+	RateLimited              = "rate_limited"
+	GroupRateLimited         = "group_rate_limited"
+	GlobalRateLimited        = "global_rate_limited"
+	QuotaExceeded            = "quota_exceeded"
+	ByteCounterError         = "byte_counter_error"
+	ByteLimitExceeded        = "byte_limit_exceeded"
+	SlotError                = "slot_error"
+	ConcurrencyLimitExceeded = "concurrency_limit_exceeded"
+	ShapingError             = "shaping_error"
+	QueueFull                = "queue_full"
+	QueueCancelled           = "queue_cancelled"
+	InvalidAPIKey            = "invalid_api_key"
+)
+
+// DefaultLanguage is used when neither the request nor the config picks one
+const DefaultLanguage = "pt-BR"
+
+// catalog holds the built-in templates of every language. Templates use
+// text/template with the fields of Params, e.g. {{.Service}}.
+var catalog = map[string]map[string]string{
+	"pt-BR": {
+		ConfigError:              "Erro ao buscar configuração de rate limit",
+		ServiceBlocked:           "Serviço bloqueado",
+		Overloaded:               "Serviço sobrecarregado: requisições de prioridade {{.Priority}} estão sendo descartadas. Tente novamente em {{.RetryAfter}}.",
+		CounterError:             "Erro interno ao contar requisições",
+		RateLimited:              "Rate limit excedido para o serviço '{{.Service}}': {{.Limit}} requisições permitidas por segundo. Bloqueado até {{.Reset}}.",
+		GroupRateLimited:         "Rate limit do grupo '{{.Group}}' excedido pelo serviço '{{.Service}}': {{.Limit}} requisições permitidas por segundo ao grupo. Bloqueado até {{.Reset}}.",
+		GlobalRateLimited:        "Limite global de requisições excedido: {{.Limit}} requisições permitidas por segundo. Bloqueado até {{.Reset}}.",
+		QuotaExceeded:            "Cota do período '{{.Period}}' esgotada para o serviço '{{.Service}}': {{.Limit}} requisições permitidas. Renova em {{.Reset}}.",
+		ByteCounterError:         "Erro interno ao contar bytes servidos",
+		ByteLimitExceeded:        "Limite de bytes excedido para o serviço '{{.Service}}': {{.Limit}} bytes permitidos por minuto. Bloqueado até {{.Reset}}.",
+		SlotError:                "Erro interno ao reservar slot de concorrência",
+		ConcurrencyLimitExceeded: "Limite de requisições simultâneas excedido para o serviço '{{.Service}}': {{.Limit}} permitidas.",
+		ShapingError:             "Erro interno ao agendar requisição",
+		QueueFull:                "Rate limit excedido para o serviço '{{.Service}}': fila de espera cheia ou atraso acima de {{.MaxDelay}}.",
+		QueueCancelled:           "Requisição cancelada enquanto aguardava na fila",
+		InvalidAPIKey:            "Chave de API ausente ou não cadastrada",
+	},
+	"en": {
+		ConfigError:              "Failed to load the rate limit configuration",
+		ServiceBlocked:           "Service blocked",
+		Overloaded:               "Service overloaded: requests of priority {{.Priority}} are being shed. Try again in {{.RetryAfter}}.",
+		CounterError:             "Internal error while counting requests",
+		RateLimited:              "Rate limit exceeded for service '{{.Service}}': {{.Limit}} requests allowed per second. Blocked until {{.Reset}}.",
+		GroupRateLimited:         "Rate limit of group '{{.Group}}' exceeded by service '{{.Service}}': {{.Limit}} requests allowed per second to the group. Blocked until {{.Reset}}.",
+		GlobalRateLimited:        "Global request limit exceeded: {{.Limit}} requests allowed per second. Blocked until {{.Reset}}.",
+		QuotaExceeded:            "Quota of period '{{.Period}}' exhausted for service '{{.Service}}': {{.Limit}} requests allowed. Renews at {{.Reset}}.",
+		ByteCounterError:         "Internal error while counting served bytes",
+		ByteLimitExceeded:        "Byte limit exceeded for service '{{.Service}}': {{.Limit}} bytes allowed per minute. Blocked until {{.Reset}}.",
+		SlotError:                "Internal error while reserving a concurrency slot",
+		ConcurrencyLimitExceeded: "Concurrent request limit exceeded for service '{{.Service}}': {{.Limit}} allowed.",
+		ShapingError:             "Internal error while scheduling the request",
+		QueueFull:                "Rate limit exceeded for service '{{.Service}}': waiting queue is full or delay is above {{.MaxDelay}}.",
+		QueueCancelled:           "Request cancelled while waiting in the queue",
+		InvalidAPIKey:            "Missing or unregistered API key",
+	},
+}
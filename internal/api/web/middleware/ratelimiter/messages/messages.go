@@ -0,0 +1,142 @@
+package messages
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Params are the values a message template may refer to
+type Params struct {
+	Service    string
+	Group      string
+	Period     string
+	Limit      int64
+	Priority   int
+	Reset      string
+	RetryAfter string
+	MaxDelay   string
+}
+
+// Overrides replace built-in templates of a service, by lowercase language
+// tag and then by code, e.g. overrides["en"][RateLimited]
+type Overrides map[string]map[string]string
+
+// Supported reports the catalog language matching tag, ignoring case
+func Supported(tag string) (string, bool) {
+	for lang := range catalog {
+		if strings.EqualFold(lang, tag) {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+// Negotiate picks the catalog language that best matches an Accept-Language
+// header (or a single tag), trying exact tags before their primary subtag, e.g.
+// "pt" or "pt-PT" for pt-BR. It falls back to fallback, then to DefaultLanguage.
+func Negotiate(acceptLanguage string, fallback string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if tag != "" && tag != "*" && q > 0 {
+			candidates = append(candidates, candidate{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		if lang, ok := Supported(c.tag); ok {
+			return lang
+		}
+		primary, _, _ := strings.Cut(c.tag, "-")
+		for lang := range catalog {
+			langPrimary, _, _ := strings.Cut(lang, "-")
+			if strings.EqualFold(langPrimary, primary) {
+				return lang
+			}
+		}
+	}
+
+	if lang, ok := Supported(fallback); ok {
+		return lang
+	}
+	return DefaultLanguage
+}
+
+// Render fills the template of code in lang, preferring the service's override
+func Render(lang string, code string, overrides Overrides, params Params) string {
+	text, ok := overrides[strings.ToLower(lang)][code]
+	if !ok {
+		text, ok = catalog[lang][code]
+	}
+	if !ok {
+		text, ok = catalog[DefaultLanguage][code]
+	}
+	if !ok {
+		return code
+	}
+
+	tmpl, err := parse(text)
+	if err != nil {
+		return code
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, params); err != nil {
+		return code
+	}
+	return out.String()
+}
+
+// Validate checks that overrides only use catalog languages and known codes
+// and that every template can be rendered
+func (o Overrides) Validate() error {
+	for lang, templates := range o {
+		if _, ok := Supported(lang); !ok {
+			return fmt.Errorf("unsupported message language %q", lang)
+		}
+		for code, text := range templates {
+			if _, ok := catalog[DefaultLanguage][code]; !ok {
+				return fmt.Errorf("unknown message code %q", code)
+			}
+			tmpl, err := parse(text)
+			if err != nil {
+				return fmt.Errorf("invalid message template for %q: %w", code, err)
+			}
+			if err := tmpl.Execute(&strings.Builder{}, Params{}); err != nil {
+				return fmt.Errorf("invalid message template for %q: %w", code, err)
+			}
+		}
+	}
+	return nil
+}
+
+// templates caches the parsed templates by their text
+var templates sync.Map
+
+func parse(text string) (*template.Template, error) {
+	if cached, ok := templates.Load(text); ok {
+		return cached.(*template.Template), nil
+	}
+	tmpl, err := template.New("message").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	templates.Store(text, tmpl)
+	return tmpl, nil
+}
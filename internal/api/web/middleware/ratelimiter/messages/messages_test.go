@@ -0,0 +1,52 @@
+package messages_test
+
+import (
+	"testing"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/messages"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate_PicksTheBestSupportedLanguage(t *testing.T) {
+	cases := map[string]string{
+		"en-US,en;q=0.9":            "en",
+		"fr-FR, pt;q=0.8, en;q=0.5": "pt-BR",
+		"de, *;q=0.1":               "pt-BR",
+		"PT-br":                     "pt-BR",
+		"":                          "pt-BR",
+	}
+
+	for accept, expected := range cases {
+		assert.Equal(t, expected, messages.Negotiate(accept, ""), accept)
+	}
+	assert.Equal(t, "en", messages.Negotiate("de", "en"))
+}
+
+func TestRender_PrefersServiceOverrides(t *testing.T) {
+	// Arrange
+	params := messages.Params{Service: "service-a", Limit: 5, Reset: "12:00:01"}
+	overrides := messages.Overrides{"en": {messages.RateLimited: "Slow down, {{.Service}}"}}
+
+	// Act
+	en := messages.Render("en", messages.RateLimited, overrides, params)
+	pt := messages.Render("pt-BR", messages.RateLimited, overrides, params)
+
+	// Assert
+	assert.Equal(t, "Slow down, service-a", en)
+	assert.Equal(t, "Rate limit excedido para o serviço 'service-a': 5 requisições permitidas por segundo. Bloqueado até 12:00:01.", pt)
+}
+
+func TestOverridesValidate_RejectsUnknownLanguagesCodesAndFields(t *testing.T) {
+	cases := []messages.Overrides{
+		{"es": {messages.RateLimited: "Más despacio"}},
+		{"en": {"too_many": "Slow down"}},
+		{"en": {messages.RateLimited: "Slow down, {{.Unknown}}"}},
+		{"en": {messages.RateLimited: "Slow down, {{.Service"}},
+	}
+
+	for _, overrides := range cases {
+		assert.Error(t, overrides.Validate(), overrides)
+	}
+	assert.NoError(t, messages.Overrides{"pt-br": {messages.ServiceBlocked: "Chave revogada"}}.Validate())
+}
@@ -1,13 +1,67 @@
 package repository
 
 import (
-	"ratelim/internal/api/web/middleware/ratelimiter/entity"
 	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
 )
 
+// CounterRef names a window counter and the TTL it gets when an increment creates it
+type CounterRef struct {
+	Key       string
+	WindowKey string
+	TTL       time.Duration
+}
+
 type Store interface {
 	SetServiceConfig(entity.ServiceConfig) error
 	GetServiceRateLimit(key string) (entity.ServiceConfig, error)
-	IncrementRequestCount(key string, windowKey string) (int, error)
+	// LookupServiceConfig returns the config registered for key without falling
+	// back to the default one or writing anything; ok is false for keys that
+	// were never registered
+	LookupServiceConfig(key string) (cfg entity.ServiceConfig, ok bool, err error)
+	// IncrementRequestCount adds amount (the request cost) to the counter and returns the new total
+	IncrementRequestCount(key string, windowKey string, amount int) (int, error)
+	// RefundRequestCount gives amount back to an existing counter, never going below zero
+	RefundRequestCount(key string, windowKey string, amount int) error
 	SetExpiration(key string, windowKey string, ttl time.Duration) error
+	// IncrementRequestCounts adds amount to every counter in a single atomic step
+	// and returns their new totals in the same order
+	IncrementRequestCounts(counters []CounterRef, amount int) ([]int, error)
+	// GetRequestCounts returns the totals of the counters without changing them;
+	// counters that do not exist yet count as 0
+	GetRequestCounts(counters []CounterRef) ([]int, error)
+
+	// GetByteCount and AdjustByteCount track the response bytes served to key in windowKey
+	GetByteCount(key string, windowKey string) (int64, error)
+	AdjustByteCount(key string, windowKey string, delta int64, ttl time.Duration) (int64, error)
+
+	// ReserveShapingSlot schedules a request of key on a leaky bucket that emits
+	// one unit every interval and admits bursts up to burst units. It returns how
+	// long the request must wait for its slot and whether it was scheduled; it is
+	// refused when the wait exceeds maxDelay or maxQueue requests already wait.
+	ReserveShapingSlot(key string, cost int, interval time.Duration, burst int, maxDelay time.Duration, maxQueue int) (wait time.Duration, ok bool, err error)
+	// ReleaseShapingSlot gives back the slot of a scheduled request that gave up
+	// waiting, so the requests behind it move up
+	ReleaseShapingSlot(key string, cost int, interval time.Duration) error
+
+	// AcquireSlot registers leaseID as an in-flight request of key when fewer than
+	// max leases are alive. Leases expire after ttl unless renewed, so slots held
+	// by a crashed instance are reclaimed.
+	AcquireSlot(key string, leaseID string, max int, ttl time.Duration) (bool, error)
+	RenewSlot(key string, leaseID string, ttl time.Duration) error
+	ReleaseSlot(key string, leaseID string) error
+	// CountSlots returns how many leases of key are still alive
+	CountSlots(key string) (int, error)
+
+	Close() error
+}
+
+// UsageStore keeps the hourly usage totals used for billing. Unlike request
+// counters, they never expire.
+type UsageStore interface {
+	// AddUsage adds the admitted and rejected counts of every record to its hour's totals
+	AddUsage(records []entity.UsageRecord) error
+	// GetUsage returns the totals of every service for the hours in [from, to)
+	GetUsage(from, to time.Time) ([]entity.UsageRecord, error)
 }
@@ -0,0 +1,88 @@
+package shedding
+
+import (
+	"sync"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+)
+
+// Controller tracks the load of this instance and decides which priorities to
+// shed. Load is measured locally: each instance protects its own capacity.
+type Controller struct {
+	config entity.SheddingConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	inFlight int
+	second   int64
+	admitted int
+}
+
+func NewController(config entity.SheddingConfig, now func() time.Time) *Controller {
+	return &Controller{config: config, now: now}
+}
+
+// SetConfig replaces the shedding config, e.g. when services are registered
+// after the controller started serving requests
+func (c *Controller) SetConfig(config entity.SheddingConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.config = config
+}
+
+// Admit reports whether a request of priority may proceed under the current
+// load, and counts it towards the requests of the current second if so
+func (c *Controller) Admit(priority int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	second := c.now().Unix()
+	if second != c.second {
+		c.second = second
+		c.admitted = 0
+	}
+
+	// Sem sinal de capacidade configurado nada é descartado
+	if c.config.Enabled() && c.load() >= c.config.Threshold(priority) {
+		return false
+	}
+	c.admitted++
+	return true
+}
+
+// load returns the highest ratio of the configured capacity signals
+func (c *Controller) load() float64 {
+	var load float64
+	if c.config.MaxInFlight > 0 {
+		load = max(load, float64(c.inFlight)/float64(c.config.MaxInFlight))
+	}
+	if c.config.MaxRPS > 0 {
+		load = max(load, float64(c.admitted)/float64(c.config.MaxRPS))
+	}
+	return load
+}
+
+// Track counts an admitted request as in flight until the returned func is
+// called. The func is safe to call more than once.
+func (c *Controller) Track() func() {
+	c.mu.Lock()
+	c.inFlight++
+	c.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			c.inFlight--
+			c.mu.Unlock()
+		})
+	}
+}
+
+// RetryAfter is how long shed callers are told to wait
+func (c *Controller) RetryAfter() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.config.RetryAfterDuration()
+}
@@ -0,0 +1,80 @@
+package shedding_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/shedding"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func TestController_ShedsLowerPrioritiesFirst(t *testing.T) {
+	// Arrange
+	clk := &clock{t: time.Unix(1000, 0)}
+	controller := shedding.NewController(entity.SheddingConfig{MaxInFlight: 10}, clk.now)
+
+	// Act
+	// 8 requests in flight: 80% load, the threshold of priority 0
+	for i := 0; i < 8; i++ {
+		controller.Track()
+	}
+	freeAt80 := controller.Admit(0)
+	standardAt80 := controller.Admit(1)
+
+	release := controller.Track()
+	standardAt90 := controller.Admit(1)
+	premiumAt90 := controller.Admit(2)
+
+	release()
+	standardAfterRelease := controller.Admit(1)
+
+	// Assert
+	assert.False(t, freeAt80)
+	assert.True(t, standardAt80)
+	assert.False(t, standardAt90)
+	assert.True(t, premiumAt90)
+	assert.True(t, standardAfterRelease)
+}
+
+func TestController_CountsAdmittedRequestsPerSecond(t *testing.T) {
+	// Arrange
+	clk := &clock{t: time.Unix(1000, 0)}
+	shedFrom := 0.5
+	controller := shedding.NewController(entity.SheddingConfig{MaxRPS: 10, ShedFrom: &shedFrom}, clk.now)
+
+	// Act
+	var admitted int
+	for i := 0; i < 10; i++ {
+		if controller.Admit(0) {
+			admitted++
+		}
+	}
+	clk.t = clk.t.Add(time.Second)
+	nextSecond := controller.Admit(0)
+
+	// Assert
+	assert.Equal(t, 5, admitted)
+	assert.True(t, nextSecond)
+	assert.Equal(t, time.Second, controller.RetryAfter())
+}
+
+func TestController_ExplicitZeroShedFromShedsPriorityZeroAtAnyLoad(t *testing.T) {
+	// Arrange
+	clk := &clock{t: time.Unix(1000, 0)}
+	shedFrom := 0.0
+	controller := shedding.NewController(entity.SheddingConfig{MaxInFlight: 10, ShedFrom: &shedFrom, PriorityStep: 0.5}, clk.now)
+
+	// Act
+	free := controller.Admit(0)
+	paid := controller.Admit(1)
+
+	// Assert
+	assert.False(t, free)
+	assert.True(t, paid)
+}
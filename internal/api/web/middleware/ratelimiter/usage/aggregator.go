@@ -0,0 +1,123 @@
+package usage
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/repository"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/infra/logging"
+)
+
+// Aggregator sums the admitted and rejected requests of each service per hour in
+// memory and periodically adds them to the UsageStore, so billing data outlives
+// the short-lived request counters without a Redis round trip per request.
+type Aggregator struct {
+	store  repository.UsageStore
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	pending map[bucket]*entity.UsageRecord
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type bucket struct {
+	service string
+	hour    time.Time
+}
+
+// NewAggregator builds an Aggregator adding to store. A nil logger logs to slog.Default().
+func NewAggregator(store repository.UsageStore, logger *slog.Logger) *Aggregator {
+	return &Aggregator{
+		store:   store,
+		logger:  logging.OrDefault(logger),
+		pending: make(map[bucket]*entity.UsageRecord),
+	}
+}
+
+// Record counts one request of service decided at at
+func (a *Aggregator) Record(service string, at time.Time, admitted bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	b := bucket{service: service, hour: entity.UsageHour(at)}
+	rec, ok := a.pending[b]
+	if !ok {
+		rec = &entity.UsageRecord{Hour: b.hour, Service: service}
+		a.pending[b] = rec
+	}
+	if admitted {
+		rec.Admitted++
+	} else {
+		rec.Rejected++
+	}
+}
+
+// Flush adds every pending count to the store. Counts that fail to be stored are
+// kept and retried on the next flush.
+func (a *Aggregator) Flush() error {
+	a.mu.Lock()
+	pending := a.pending
+	a.pending = make(map[bucket]*entity.UsageRecord)
+	a.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	records := make([]entity.UsageRecord, 0, len(pending))
+	for _, rec := range pending {
+		records = append(records, *rec)
+	}
+	err := a.store.AddUsage(records)
+	if err == nil {
+		return nil
+	}
+
+	// Devolver as contagens para a próxima tentativa
+	a.mu.Lock()
+	for b, rec := range pending {
+		if current, ok := a.pending[b]; ok {
+			current.Admitted += rec.Admitted
+			current.Rejected += rec.Rejected
+			continue
+		}
+		a.pending[b] = rec
+	}
+	a.mu.Unlock()
+	return err
+}
+
+// Start flushes the pending counts every interval until Close is called
+func (a *Aggregator) Start(interval time.Duration) {
+	a.stop = make(chan struct{})
+	a.done = make(chan struct{})
+
+	go func() {
+		defer close(a.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := a.Flush(); err != nil {
+					a.logger.Warn("falha ao gravar uso agregado", "error", err)
+				}
+			case <-a.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the flush loop and stores whatever is still pending
+func (a *Aggregator) Close() error {
+	if a.stop != nil {
+		close(a.stop)
+		<-a.done
+	}
+	return a.Flush()
+}
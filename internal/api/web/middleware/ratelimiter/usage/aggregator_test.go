@@ -0,0 +1,80 @@
+package usage_test
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUsageStore keeps the stored records and can be told to fail
+type fakeUsageStore struct {
+	records []entity.UsageRecord
+	fail    bool
+}
+
+func (f *fakeUsageStore) AddUsage(records []entity.UsageRecord) error {
+	if f.fail {
+		return errors.New("store unavailable")
+	}
+	f.records = append(f.records, records...)
+	return nil
+}
+
+func (f *fakeUsageStore) GetUsage(from, to time.Time) ([]entity.UsageRecord, error) {
+	return f.records, nil
+}
+
+func TestAggregator_SumsPerServiceAndHour(t *testing.T) {
+	// Arrange
+	store := &fakeUsageStore{}
+	aggregator := usage.NewAggregator(store, nil)
+	hour := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+
+	// Act
+	aggregator.Record("service-a", hour.Add(10*time.Minute), true)
+	aggregator.Record("service-a", hour.Add(50*time.Minute), true)
+	aggregator.Record("service-a", hour.Add(59*time.Minute), false)
+	aggregator.Record("service-a", hour.Add(61*time.Minute), true)
+	aggregator.Record("service-b", hour, false)
+	err := aggregator.Flush()
+
+	// Assert
+	require.NoError(t, err)
+	sort.Slice(store.records, func(i, j int) bool {
+		if !store.records[i].Hour.Equal(store.records[j].Hour) {
+			return store.records[i].Hour.Before(store.records[j].Hour)
+		}
+		return store.records[i].Service < store.records[j].Service
+	})
+	assert.Equal(t, []entity.UsageRecord{
+		{Hour: hour, Service: "service-a", Admitted: 2, Rejected: 1},
+		{Hour: hour, Service: "service-b", Rejected: 1},
+		{Hour: hour.Add(time.Hour), Service: "service-a", Admitted: 1},
+	}, store.records)
+}
+
+func TestAggregator_KeepsCountsWhenStoreFails(t *testing.T) {
+	// Arrange
+	store := &fakeUsageStore{fail: true}
+	aggregator := usage.NewAggregator(store, nil)
+	hour := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	aggregator.Record("service-a", hour, true)
+
+	// Act
+	failed := aggregator.Flush()
+	aggregator.Record("service-a", hour, true)
+	store.fail = false
+	err := aggregator.Close()
+
+	// Assert
+	assert.Error(t, failed)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.UsageRecord{{Hour: hour, Service: "service-a", Admitted: 2}}, store.records)
+}
@@ -0,0 +1,66 @@
+package verify
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+)
+
+// AuditEvent records why a request was refused. It never carries the raw key,
+// only its fingerprint.
+type AuditEvent struct {
+	Timestamp      time.Time `json:"timestamp"`
+	Service        string    `json:"service"`
+	KeyFingerprint string    `json:"key_fingerprint"`
+	ClientIP       string    `json:"client_ip"`
+	Method         string    `json:"method,omitempty"`
+	Route          string    `json:"route,omitempty"`
+	Status         int       `json:"status"`
+	// Limit is the config field of the limit that applied and LimitValue its value
+	Limit      string `json:"limit,omitempty"`
+	LimitValue int    `json:"limit_value,omitempty"`
+	Level      string `json:"level,omitempty"`
+	Code       string `json:"code,omitempty"`
+	// Count is what was counted against the limit, the refused request included
+	Count int `json:"count"`
+	// ResetAt is when the caller is unblocked, zero when it does not depend on a window
+	ResetAt time.Time `json:"reset_at,omitzero"`
+	Message string    `json:"message"`
+	// Suppressed is how many events of the same status and level were dropped
+	// by sampling since the previous one was recorded
+	Suppressed int `json:"suppressed,omitempty"`
+}
+
+// audited tells whether the decision status must be recorded in the audit log
+func audited(status int) bool {
+	switch status {
+	case http.StatusForbidden, http.StatusTooManyRequests, http.StatusInternalServerError:
+		return true
+	}
+	return false
+}
+
+// audit hands the decision to the AuditRecorder when it refused the request
+func (v *VerifyUsecase) audit(ctx context.Context, input VerifyInputDTO, decision VerifyOutputDTO, at time.Time) {
+	if v.Audit == nil || !decision.Blocked || !audited(decision.Status) {
+		return
+	}
+	v.Audit.RecordAudit(ctx, AuditEvent{
+		Timestamp:      at,
+		Service:        decision.Name,
+		KeyFingerprint: entity.KeyFingerprint(decision.Key),
+		ClientIP:       input.ClientIp,
+		Method:         input.Method,
+		Route:          input.Path,
+		Status:         decision.Status,
+		Limit:          decision.LimitName,
+		LimitValue:     decision.Limit,
+		Level:          decision.Level,
+		Code:           decision.Code,
+		Count:          decision.Count,
+		ResetAt:        decision.ResetAt,
+		Message:        decision.Message,
+	})
+}
@@ -0,0 +1,61 @@
+package verify_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditCollector keeps the audit events it is given
+type auditCollector struct {
+	events []v.AuditEvent
+}
+
+func (a *auditCollector) RecordAudit(ctx context.Context, event v.AuditEvent) {
+	a.events = append(a.events, event)
+}
+
+func TestVerify_AuditsRefusalsWithKeyFingerprint(t *testing.T) {
+	// Arrange
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "service-a", Key: "s3cr3t-key", Valid: true, AllowedRPS: 1,
+	}}
+	auditor := &auditCollector{}
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, auditor, nil)
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	usecase.Clock = func() time.Time { return now }
+	input := v.VerifyInputDTO{ApiKey: "s3cr3t-key", ClientIp: "10.0.0.7", Method: http.MethodGet, Path: "/reports"}
+
+	// Act
+	allowed := usecase.Verify(context.Background(), input)
+	limited := usecase.Verify(context.Background(), input)
+	store.config.Valid = false
+	usecase.Verify(context.Background(), input)
+
+	// Assert
+	assert.False(t, allowed.Blocked)
+	assert.True(t, limited.Blocked)
+	require.Len(t, auditor.events, 2)
+
+	event := auditor.events[0]
+	assert.Equal(t, now, event.Timestamp)
+	assert.Equal(t, "service-a", event.Service)
+	assert.Equal(t, entity.KeyFingerprint("s3cr3t-key"), event.KeyFingerprint)
+	assert.NotContains(t, event.KeyFingerprint, "s3cr3t")
+	assert.Equal(t, "10.0.0.7", event.ClientIP)
+	assert.Equal(t, "/reports", event.Route)
+	assert.Equal(t, http.StatusTooManyRequests, event.Status)
+	assert.Equal(t, "allowed_rps", event.Limit)
+	assert.Equal(t, 1, event.LimitValue)
+	assert.Equal(t, 2, event.Count)
+	assert.Equal(t, limited.ResetAt, event.ResetAt)
+
+	assert.Equal(t, http.StatusForbidden, auditor.events[1].Status)
+}
@@ -0,0 +1,53 @@
+package verify_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/shedding"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify_ShedRequestsResetOnTheUsecaseClock(t *testing.T) {
+	// Arrange
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "service-a", Key: "s3rv1ce", Valid: true, AllowedRPS: 100,
+	}}
+	shedder := shedding.NewController(entity.SheddingConfig{MaxRPS: 1, RetryAfter: "2s"}, clock)
+	usecase := v.NewVerifyUsecase(store, nil, nil, shedder, nil, nil, nil, nil)
+	usecase.Clock = clock
+	input := v.VerifyInputDTO{ApiKey: "s3rv1ce"}
+
+	// Act
+	usecase.Verify(context.Background(), input)
+	shed := usecase.Verify(context.Background(), input)
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, shed.Status)
+	assert.True(t, shed.ResetAt.Equal(now.Add(2*time.Second)))
+}
+
+func TestVerify_ShapedRequestsResetOnTheUsecaseClock(t *testing.T) {
+	// Arrange
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "batch", Key: "b4tch", Valid: true, AllowedRPS: 10, Shaping: true, MaxDelay: "1s",
+	}}
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+	usecase.Clock = func() time.Time { return now }
+
+	// Act
+	shaped := usecase.Verify(context.Background(), v.VerifyInputDTO{ApiKey: "b4tch"})
+
+	// Assert
+	assert.False(t, shaped.Blocked)
+	// 10 requests per 10s window: the next slot is a second away
+	assert.True(t, shaped.ResetAt.Equal(now.Add(time.Second)))
+}
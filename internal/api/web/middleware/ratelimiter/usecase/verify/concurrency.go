@@ -0,0 +1,130 @@
+package verify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/messages"
+)
+
+// holdSlot reserves a concurrency slot for an admitted request when the caller
+// tracks in-flight requests and the service limits them. The returned output
+// carries the Release func, or the rejection if no slot is free.
+func (v *VerifyUsecase) holdSlot(ctx context.Context, input VerifyInputDTO, config entity.ServiceConfig, admitted VerifyOutputDTO) VerifyOutputDTO {
+	if !input.TrackInFlight || config.MaxConcurrent <= 0 {
+		return admitted
+	}
+
+	release, acquired, err := v.acquireSlot(config)
+	if err != nil {
+		return VerifyOutputDTO{
+			Key:     admitted.Key,
+			Name:    config.Name,
+			Blocked: true,
+			Message: message(input, config, messages.SlotError, messages.Params{Service: config.Name}),
+			Status:  http.StatusInternalServerError,
+			Code:    messages.SlotError,
+		}
+	}
+	if !acquired {
+		blocked := VerifyOutputDTO{
+			Key:     admitted.Key,
+			Name:    config.Name,
+			Blocked: true,
+			Message: message(input, config, messages.ConcurrencyLimitExceeded, messages.Params{
+				Service: config.Name,
+				Limit:   int64(config.MaxConcurrent),
+			}),
+			Status:    http.StatusTooManyRequests,
+			Count:     config.MaxConcurrent,
+			LimitName: "max_concurrent",
+			Code:      messages.ConcurrencyLimitExceeded,
+		}
+		if !v.enforce(ctx, input, config, blocked) {
+			// Em modo shadow a requisição segue sem ocupar slot
+			return admitted
+		}
+		// A requisição não será atendida, então o custo reservado é devolvido
+		if admitted.counter != nil {
+			_ = admitted.counter.refund(admitted.Cost)
+		}
+		return blocked
+	}
+
+	admitted.Release = release
+	return admitted
+}
+
+// trackInFlight counts an admitted request towards the in-flight load seen by
+// the shedder until it is released
+func (v *VerifyUsecase) trackInFlight(input VerifyInputDTO, admitted VerifyOutputDTO) VerifyOutputDTO {
+	if admitted.Blocked || !input.TrackInFlight || v.Shedder == nil {
+		return admitted
+	}
+
+	done := v.Shedder.Track()
+	release := admitted.Release
+	admitted.Release = func() {
+		done()
+		if release != nil {
+			release()
+		}
+	}
+	return admitted
+}
+
+// acquireSlot takes an in-flight lease for the service and keeps renewing it
+// until the returned release func is called. ok is false when the service
+// already has max_concurrent requests in flight.
+func (v *VerifyUsecase) acquireSlot(config entity.ServiceConfig) (release func(), ok bool, err error) {
+	leaseID, err := newLeaseID()
+	if err != nil {
+		return nil, false, err
+	}
+
+	ttl := config.LeaseTTL()
+	ok, err = v.RateLimiterRepository.AcquireSlot(config.Key, leaseID, config.MaxConcurrent, ttl)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+
+	// Renovar o lease enquanto a requisição estiver em andamento
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(ttl / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_ = v.RateLimiterRepository.RenewSlot(config.Key, leaseID, ttl)
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	release = func() {
+		once.Do(func() {
+			close(stop)
+			if err := v.RateLimiterRepository.ReleaseSlot(config.Key, leaseID); err != nil {
+				// O lease expira sozinho após o TTL
+				v.Logger.Warn("falha ao liberar slot de concorrência", "service", config.Name, "error", err)
+			}
+		})
+	}
+	return release, true, nil
+}
+
+func newLeaseID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,42 @@
+package verify_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify_ChargesRouteAndHandlerCosts(t *testing.T) {
+	// Arrange
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "bulk", Type: "token", Key: "bu1k", Valid: true, AllowedRPS: 100,
+		Routes: []entity.RouteRule{{Prefix: "/bulk", Cost: 40}},
+	}}
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+	usecase.Clock = func() time.Time { return time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC) }
+	verify := func(path string) v.VerifyOutputDTO {
+		return usecase.Verify(context.Background(), v.VerifyInputDTO{ApiKey: "bu1k", Method: http.MethodGet, Path: path})
+	}
+
+	// Act
+	bulk := verify("/bulk")
+	report := verify("/report")
+	require.NoError(t, usecase.Settle(context.Background(), report, v.SettleInputDTO{Status: http.StatusOK, Cost: 60}))
+	single := verify("/lookup")
+
+	// Assert
+	assert.Equal(t, 40, bulk.Cost)
+	assert.Equal(t, 60, bulk.Remaining)
+	assert.Equal(t, 1, report.Cost)
+	// 40 + 60 reported by the handler leave no room for the next request
+	assert.True(t, single.Blocked)
+	assert.Equal(t, http.StatusTooManyRequests, single.Status)
+	assert.Equal(t, 100, single.Limit)
+}
@@ -1,8 +1,21 @@
 package verify
 
+import "time"
+
 type VerifyInputDTO struct {
 	ApiKey   string `json:"api_key"`
 	ClientIp string `json:"client_ip"`
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	// Cost overrides the cost resolved from the service's route rules when > 0
+	Cost int `json:"cost"`
+	// Language is the caller's Accept-Language (or a single tag) used to pick
+	// the language of the message; empty uses the service's language
+	Language string `json:"language"`
+	// TrackInFlight asks Verify to hold a concurrency slot for services with
+	// max_concurrent. Only callers that can call Release once the request is
+	// done (i.e. middlewares, not decision endpoints) should set it.
+	TrackInFlight bool `json:"-"`
 }
 
 type VerifyOutputDTO struct {
@@ -11,4 +24,93 @@ type VerifyOutputDTO struct {
 	Blocked bool   `json:"blocked"`
 	Message string `json:"message"`
 	Status  int    `json:"status"`
+	// Limit, Remaining and ResetAt describe the window the request was counted in.
+	// They are zero when the request was rejected before being counted.
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+	Cost      int       `json:"cost"`
+	// Count is what was counted against the limit that applied, this request included
+	Count int `json:"count"`
+	// LimitName is the config field of the limit that blocked the request
+	// (e.g. "allowed_rps"), or would have in shadow mode
+	LimitName string `json:"limit_name"`
+	// Level tells whether the service's own limit (LevelKey) or the one shared
+	// with its group (LevelGroup) blocked the request
+	Level string `json:"level"`
+	// Code is the stable, machine-readable kind of rejection (see package
+	// messages), set on every refusal whatever the language of Message
+	Code string `json:"code"`
+	// Quotas reports the calendar quotas of the service, when it has any
+	Quotas []QuotaUsage `json:"quotas"`
+	// Schedule names the schedule entry whose limit applied, if any
+	Schedule string `json:"schedule"`
+	// ErrorFormat tells how a refusal must be written (entity.ErrorFormatProblem
+	// or entity.ErrorFormatLegacy) and ProblemType is the "type" member of its
+	// problem+json body. Both are only set on refusals.
+	ErrorFormat string `json:"error_format"`
+	ProblemType string `json:"problem_type"`
+	// Delay is how long a shaped request waited for its slot
+	Delay time.Duration `json:"delay"`
+
+	// Release frees the concurrency slot held for the request. It is nil when no
+	// slot was taken and safe to call more than once.
+	Release func() `json:"-"`
+
+	// counter is the window the request was counted in, used by Settle
+	counter *windowCounter
+}
+
+// QuotaUsage is the state of a calendar quota after the request was counted
+type QuotaUsage struct {
+	Period    string    `json:"period"`
+	Limit     int64     `json:"limit"`
+	Remaining int64     `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+// SettleInputDTO describes how an admitted request ended
+type SettleInputDTO struct {
+	// Status is the HTTP status sent to the client
+	Status int `json:"status"`
+	// Size is the number of body bytes sent to the client
+	Size int64 `json:"size"`
+	// Cost is the real cost reported by the handler, or 0 to keep the reserved one
+	Cost int `json:"cost"`
+	// Latency is how long the handler took, observed by adaptive services
+	Latency time.Duration `json:"latency"`
+}
+
+// StatusOutputDTO is the current usage of every limit of the caller's service
+type StatusOutputDTO struct {
+	Name   string        `json:"service"`
+	Limits []LimitStatus `json:"limits"`
+	// Block is set while the service's requests are being refused
+	Block *BlockStatus `json:"block,omitempty"`
+
+	// Refusal is set, with Blocked true, when the status cannot be reported,
+	// e.g. for a missing or unregistered API key
+	Refusal VerifyOutputDTO `json:"-"`
+}
+
+// LimitStatus is the usage of one limit, named after its config field (e.g.
+// "allowed_rps" or "quota_month")
+type LimitStatus struct {
+	Name      string    `json:"name"`
+	Level     string    `json:"level,omitempty"`
+	Limit     int64     `json:"limit"`
+	Used      int64     `json:"used"`
+	Remaining int64     `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at,omitzero"`
+	Schedule  string    `json:"schedule,omitempty"`
+	// Shaping limits delay requests instead of counting them, so Used stays 0
+	Shaping bool `json:"shaping,omitempty"`
+}
+
+// BlockStatus tells why the service's requests are refused and until when;
+// Until is zero when the block only ends by changing the config
+type BlockStatus struct {
+	Code    string    `json:"code"`
+	Message string    `json:"message"`
+	Until   time.Time `json:"until,omitzero"`
 }
@@ -0,0 +1,89 @@
+package verify_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify_GlobalLimitKeepsReservedShareForPriorityServices(t *testing.T) {
+	// Arrange
+	services := []*entity.ServiceConfig{
+		{Name: "free-1", Type: "token", Key: "fr33one", Valid: true, AllowedRPS: 100},
+		{Name: "free-2", Type: "token", Key: "fr33two", Valid: true, AllowedRPS: 100},
+		{Name: "gold", Type: "token", Key: "g0ld", Valid: true, AllowedRPS: 100},
+		{Name: "vip", Type: "token", Key: "v1p", Valid: true, AllowedRPS: 100},
+	}
+	// Half of the 4 requests per second are kept for gold and vip
+	global := entity.GlobalConfig{
+		AllowedRPS:    4,
+		ReservedShare: 0.5,
+		PriorityServices: []entity.PriorityService{
+			{Name: "gold"},
+			{Name: "vip", Bypass: true},
+		},
+	}
+	require.NoError(t, global.Apply(services))
+	keys := map[string]entity.ServiceConfig{}
+	for _, s := range services {
+		keys[s.Key] = *s
+	}
+	store := &fakeStore{counters: map[string]int{}}
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+	usecase.Clock = func() time.Time { return time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC) }
+	verify := func(key string) v.VerifyOutputDTO {
+		store.config = keys[key]
+		return usecase.Verify(context.Background(), v.VerifyInputDTO{ApiKey: key})
+	}
+
+	// Act
+	var outputs []v.VerifyOutputDTO
+	for _, key := range []string{"fr33one", "fr33two"} {
+		outputs = append(outputs, verify(key))
+	}
+	freeBlocked := verify("fr33one")
+	for _, key := range []string{"g0ld", "g0ld", "g0ld", "v1p"} {
+		outputs = append(outputs, verify(key))
+	}
+
+	// Assert
+	assert.True(t, freeBlocked.Blocked)
+	assert.Equal(t, v.LevelGlobal, freeBlocked.Level)
+	var blocked []bool
+	for _, out := range outputs {
+		blocked = append(blocked, out.Blocked)
+	}
+	// Gold takes the reserved share and vip bypasses the exhausted limit
+	assert.Equal(t, []bool{false, false, false, false, true, false}, blocked)
+}
+
+func TestVerify_ShapedServicesAreHeldToTheGlobalLimit(t *testing.T) {
+	// Arrange
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "batch", Type: "token", Key: "b4tch", Valid: true, AllowedRPS: 10, Shaping: true,
+		GlobalAllowedRPS: 1,
+	}}
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	usecase.Clock = func() time.Time { return now }
+	input := v.VerifyInputDTO{ApiKey: "b4tch"}
+
+	// Act
+	admitted := usecase.Verify(context.Background(), input)
+	blocked := usecase.Verify(context.Background(), input)
+
+	// Assert
+	assert.False(t, admitted.Blocked)
+	assert.True(t, blocked.Blocked)
+	assert.Equal(t, v.LevelGlobal, blocked.Level)
+	assert.Equal(t, "global_allowed_rps", blocked.LimitName)
+	// Only the admitted request counts for the global limit
+	assert.Equal(t, map[string]int{fmt.Sprintf("global:%d", now.Unix()): 1}, store.counters)
+}
@@ -0,0 +1,97 @@
+package verify_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify_GroupQuotaIsSharedByItsKeys(t *testing.T) {
+	// Arrange
+	keys := map[string]entity.ServiceConfig{
+		"acme1": {Name: "acme-1", Type: "token", Key: "acme1", Valid: true, AllowedRPS: 10, Group: "acme", GroupAllowedRPS: 3},
+		"acme2": {Name: "acme-2", Type: "token", Key: "acme2", Valid: true, AllowedRPS: 10, Group: "acme", GroupAllowedRPS: 3},
+	}
+	store := &fakeStore{counters: map[string]int{}}
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+	usecase.Clock = func() time.Time { return time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC) }
+	verify := func(key string) v.VerifyOutputDTO {
+		store.config = keys[key]
+		return usecase.Verify(context.Background(), v.VerifyInputDTO{ApiKey: key})
+	}
+
+	// Act
+	admitted := []v.VerifyOutputDTO{verify("acme1"), verify("acme2"), verify("acme1")}
+	blocked := verify("acme2")
+
+	// Assert
+	for _, out := range admitted {
+		assert.False(t, out.Blocked)
+	}
+	assert.True(t, blocked.Blocked)
+	assert.Equal(t, v.LevelGroup, blocked.Level)
+	assert.Equal(t, 3, blocked.Limit)
+}
+
+func TestVerify_KeyRefusalsDoNotConsumeTheGroup(t *testing.T) {
+	// Arrange
+	first := entity.ServiceConfig{Name: "acme-1", Type: "token", Key: "acme1", Valid: true, AllowedRPS: 1, Group: "acme", GroupAllowedRPS: 3}
+	second := entity.ServiceConfig{Name: "acme-2", Type: "token", Key: "acme2", Valid: true, AllowedRPS: 10, Group: "acme", GroupAllowedRPS: 3}
+	store := &fakeStore{counters: map[string]int{}, config: first}
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+	usecase.Clock = func() time.Time { return time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC) }
+
+	// Act
+	admitted := usecase.Verify(context.Background(), v.VerifyInputDTO{ApiKey: "acme1"})
+	var retries []v.VerifyOutputDTO
+	for range 5 {
+		retries = append(retries, usecase.Verify(context.Background(), v.VerifyInputDTO{ApiKey: "acme1"}))
+	}
+	store.config = second
+	other := []v.VerifyOutputDTO{
+		usecase.Verify(context.Background(), v.VerifyInputDTO{ApiKey: "acme2"}),
+		usecase.Verify(context.Background(), v.VerifyInputDTO{ApiKey: "acme2"}),
+	}
+
+	// Assert
+	assert.False(t, admitted.Blocked)
+	for _, out := range retries {
+		assert.True(t, out.Blocked)
+		assert.Equal(t, v.LevelKey, out.Level)
+	}
+	// Only the admitted request of acme-1 counts for the group
+	for _, out := range other {
+		assert.False(t, out.Blocked)
+	}
+}
+
+func TestVerify_ShapedServicesAreCountedInTheirGroup(t *testing.T) {
+	// Arrange
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "acme-batch", Type: "token", Key: "acmeb", Valid: true, AllowedRPS: 10, Shaping: true,
+		Group: "acme", GroupAllowedRPS: 2,
+	}}
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	usecase.Clock = func() time.Time { return now }
+	input := v.VerifyInputDTO{ApiKey: "acmeb"}
+
+	// Act
+	first := usecase.Verify(context.Background(), input)
+	second := usecase.Verify(context.Background(), input)
+	blocked := usecase.Verify(context.Background(), input)
+
+	// Assert
+	assert.False(t, first.Blocked)
+	assert.False(t, second.Blocked)
+	assert.True(t, blocked.Blocked)
+	assert.Equal(t, v.LevelGroup, blocked.Level)
+	// The refused request gave its cost back to the group
+	assert.Equal(t, map[string]int{fmt.Sprintf("group:acme:%d", now.Unix()/2): 2}, store.counters)
+}
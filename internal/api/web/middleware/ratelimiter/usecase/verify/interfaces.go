@@ -4,4 +4,21 @@ import "context"
 
 type VerifyUsecaseInterface interface {
 	Verify(ctx context.Context, input VerifyInputDTO) VerifyOutputDTO
+	Settle(ctx context.Context, verified VerifyOutputDTO, result SettleInputDTO) error
+}
+
+type StatusUsecaseInterface interface {
+	Status(ctx context.Context, input VerifyInputDTO) StatusOutputDTO
+}
+
+// ShadowRecorder is told about every request a limit in shadow mode would have
+// blocked; service is the configured service the limit belongs to (see
+// entity.ServiceConfig.ConfiguredName)
+type ShadowRecorder interface {
+	RecordShadowBlock(ctx context.Context, service string, blocked VerifyOutputDTO)
+}
+
+// AuditRecorder is told about every 403, 429 and 500 decision of Verify
+type AuditRecorder interface {
+	RecordAudit(ctx context.Context, event AuditEvent)
 }
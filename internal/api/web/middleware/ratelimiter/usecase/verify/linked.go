@@ -0,0 +1,168 @@
+package verify
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/messages"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/repository"
+)
+
+// Levels a request can be blocked at
+const (
+	LevelKey    = "key"
+	LevelGroup  = "group"
+	LevelGlobal = "global"
+	LevelQuota  = "quota"
+)
+
+// CodeQuotaExceeded is the error code of requests blocked by a calendar quota
+const CodeQuotaExceeded = messages.QuotaExceeded
+
+// globalWindowTTL keeps the one second global windows a little past their second
+const globalWindowTTL = 5 * time.Second
+
+// quotaRetention keeps quota counters a day past the end of their period
+const quotaRetention = 24 * time.Hour
+
+// linkedWindow is a window counted along with the service's own, in the same
+// Store call: the one shared by its group, the global one or a calendar quota
+type linkedWindow struct {
+	ref     repository.CounterRef
+	level   string
+	limit   int // 0 counts requests without ever blocking them
+	resetAt time.Time
+	count   int // total after the last add
+	// admittedOnly windows give back the cost of blocked requests
+	admittedOnly bool
+	// period is the quota period (day, week or month)
+	period string
+}
+
+// linkedWindows returns the group, global and quota windows the service is counted in
+func linkedWindows(config entity.ServiceConfig, now time.Time) []*linkedWindow {
+	var windows []*linkedWindow
+
+	if config.Group != "" && config.GroupAllowedRPS > 0 {
+		// Mesma regra de janela dos serviços, com o limite do grupo
+		windowSize := int64(config.GroupAllowedRPS)
+		windowTimestamp := now.Unix() / windowSize
+		windows = append(windows, &linkedWindow{
+			ref: repository.CounterRef{
+				Key:       "group:" + config.Group,
+				WindowKey: fmt.Sprintf("%d", windowTimestamp),
+				TTL:       time.Duration(windowSize+5) * time.Second,
+			},
+			level:   LevelGroup,
+			limit:   config.GroupAllowedRPS,
+			resetAt: time.Unix((windowTimestamp+1)*windowSize, 0),
+			// Uma chave bloqueada pelo próprio limite não consome o grupo
+			admittedOnly: true,
+		})
+	}
+
+	if config.GlobalAllowedRPS > 0 {
+		// O limite global protege o backend, então a janela é de um segundo
+		limit := config.GlobalAllowedRPS
+		if config.GlobalBypass {
+			limit = 0
+		}
+		windows = append(windows, &linkedWindow{
+			ref: repository.CounterRef{
+				Key:       "global",
+				WindowKey: fmt.Sprintf("%d", now.Unix()),
+				TTL:       globalWindowTTL,
+			},
+			level:        LevelGlobal,
+			limit:        limit,
+			resetAt:      time.Unix(now.Unix()+1, 0),
+			admittedOnly: true,
+		})
+	}
+
+	// Cotas alinhadas ao calendário no fuso do serviço
+	loc := config.Location()
+	for _, q := range config.Quotas {
+		_, end := q.Bounds(now, loc)
+		windows = append(windows, &linkedWindow{
+			ref: repository.CounterRef{
+				Key:       "quota:" + config.Key,
+				WindowKey: q.PeriodKey(now, loc),
+				TTL:       end.Sub(now) + quotaRetention,
+			},
+			level:        LevelQuota,
+			limit:        int(q.Limit),
+			resetAt:      end,
+			admittedOnly: true,
+			period:       q.Period,
+		})
+	}
+
+	return windows
+}
+
+func (l *linkedWindow) exceeded() bool {
+	return l.limit > 0 && l.count > l.limit
+}
+
+// blocked builds the rejection of a request that exceeded the window
+func (l *linkedWindow) blocked(key string, input VerifyInputDTO, config entity.ServiceConfig) VerifyOutputDTO {
+	out := VerifyOutputDTO{
+		Key:     key,
+		Name:    config.Name,
+		Blocked: true,
+		Status:  http.StatusTooManyRequests,
+		Limit:   l.limit,
+		ResetAt: l.resetAt,
+		Level:   l.level,
+		Count:   l.count,
+	}
+	switch l.level {
+	case LevelGroup:
+		out.LimitName = "group_allowed_rps"
+		out.Code = messages.GroupRateLimited
+		out.Message = message(input, config, out.Code, messages.Params{
+			Service: config.Name,
+			Group:   config.Group,
+			Limit:   int64(l.limit),
+			Reset:   l.resetAt.Format("15:04:05"),
+		})
+	case LevelGlobal:
+		out.LimitName = "global_allowed_rps"
+		out.Code = messages.GlobalRateLimited
+		out.Message = message(input, config, out.Code, messages.Params{
+			Service: config.Name,
+			Limit:   int64(l.limit),
+			Reset:   l.resetAt.Format("15:04:05"),
+		})
+	case LevelQuota:
+		out.LimitName = "quota_" + l.period
+		out.Code = CodeQuotaExceeded
+		out.Message = message(input, config, out.Code, messages.Params{
+			Service: config.Name,
+			Period:  l.period,
+			Limit:   int64(l.limit),
+			Reset:   l.resetAt.Format(time.RFC3339),
+		})
+	}
+	return out
+}
+
+// quotaUsages reports the remaining requests of every quota window
+func quotaUsages(linked []*linkedWindow) []QuotaUsage {
+	var usages []QuotaUsage
+	for _, l := range linked {
+		if l.level != LevelQuota {
+			continue
+		}
+		usages = append(usages, QuotaUsage{
+			Period:    l.period,
+			Limit:     int64(l.limit),
+			Remaining: int64(max(l.limit-l.count, 0)),
+			ResetAt:   l.resetAt,
+		})
+	}
+	return usages
+}
@@ -0,0 +1,71 @@
+package verify_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/infra/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify_LogsRefusalsWithRequestAttributes(t *testing.T) {
+	// Arrange
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "service-a", Key: "s3cr3t-key", Valid: true, AllowedRPS: 1,
+	}}
+	var out bytes.Buffer
+	logger, err := logging.New(&out, "debug", "json")
+	require.NoError(t, err)
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, logger)
+	ctx := logging.WithAttrs(context.Background(), slog.String("request_id", "req-1"))
+	input := v.VerifyInputDTO{ApiKey: "s3cr3t-key"}
+
+	// Act
+	usecase.Verify(ctx, input)
+	usecase.Verify(ctx, input)
+
+	// Assert
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.NotContains(t, out.String(), "s3cr3t")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, "DEBUG", record["level"])
+	assert.Equal(t, "req-1", record["request_id"])
+	assert.Equal(t, "service-a", record["service"])
+	assert.Equal(t, entity.KeyFingerprint("s3cr3t-key"), record["key_fingerprint"])
+	assert.Equal(t, "blocked", record["decision"])
+	assert.Equal(t, float64(http.StatusTooManyRequests), record["status"])
+	assert.Equal(t, "allowed_rps", record["limit"])
+}
+
+func TestVerify_LogsOnlyInternalErrorsAtInfoLevel(t *testing.T) {
+	// Arrange
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "service-a", Key: "s3cr3t-key", Valid: true, AllowedRPS: 1,
+	}}
+	var out bytes.Buffer
+	logger, err := logging.New(&out, "info", "json")
+	require.NoError(t, err)
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, logger)
+	input := v.VerifyInputDTO{ApiKey: "s3cr3t-key"}
+
+	// Act
+	for range 5 {
+		usecase.Verify(context.Background(), input)
+	}
+
+	// Assert
+	// A flood of refusals does not reach the info level logs
+	assert.Empty(t, out.String())
+}
@@ -0,0 +1,14 @@
+package verify
+
+import (
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/messages"
+)
+
+// message renders the text of code in the language negotiated from the
+// request's Accept-Language and the service's language, honouring the
+// service's template overrides
+func message(input VerifyInputDTO, config entity.ServiceConfig, code string, params messages.Params) string {
+	lang := messages.Negotiate(input.Language, config.Language)
+	return messages.Render(lang, code, config.Messages, params)
+}
@@ -0,0 +1,31 @@
+package verify_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/messages"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify_MessagesFollowAcceptLanguageAndOverrides(t *testing.T) {
+	// Arrange
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "service-a", Key: "abcd1234", Valid: false, Language: "pt-BR",
+		Messages: messages.Overrides{"en": {messages.ServiceBlocked: "Key {{.Service}} was revoked"}},
+	}}
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+
+	// Act
+	english := usecase.Verify(context.Background(), v.VerifyInputDTO{ApiKey: "abcd1234", Language: "en-GB,pt;q=0.5"})
+	fallback := usecase.Verify(context.Background(), v.VerifyInputDTO{ApiKey: "abcd1234", Language: "ja"})
+
+	// Assert
+	assert.Equal(t, "Key service-a was revoked", english.Message)
+	assert.Equal(t, "Serviço bloqueado", fallback.Message)
+	assert.Equal(t, messages.ServiceBlocked, english.Code)
+	assert.Equal(t, messages.ServiceBlocked, fallback.Code)
+}
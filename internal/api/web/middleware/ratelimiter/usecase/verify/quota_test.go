@@ -0,0 +1,80 @@
+package verify_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify_MonthlyQuotaResetsOnTheFirstOfTheMonth(t *testing.T) {
+	// Arrange
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "partner", Key: "partn3r", Valid: true, AllowedRPS: 100,
+		Quotas: []entity.QuotaConfig{{Period: entity.QuotaMonth, Limit: 2}},
+	}}
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+	input := v.VerifyInputDTO{ApiKey: "partn3r"}
+
+	verifyAt := func(at time.Time) v.VerifyOutputDTO {
+		usecase.Clock = func() time.Time { return at }
+		return usecase.Verify(context.Background(), input)
+	}
+
+	// Act
+	first := verifyAt(time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC))
+	second := verifyAt(time.Date(2025, 6, 20, 12, 0, 0, 0, time.UTC))
+	exhausted := verifyAt(time.Date(2025, 6, 30, 23, 59, 0, 0, time.UTC))
+	nextMonth := verifyAt(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC))
+
+	// Assert
+	assert.Equal(t, []v.QuotaUsage{{
+		Period:    entity.QuotaMonth,
+		Limit:     2,
+		Remaining: 1,
+		ResetAt:   time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+	}}, first.Quotas)
+	assert.False(t, second.Blocked)
+	assert.Equal(t, http.StatusTooManyRequests, exhausted.Status)
+	assert.Equal(t, v.CodeQuotaExceeded, exhausted.Code)
+	assert.Equal(t, "quota_month", exhausted.LimitName)
+	// The blocked request did not consume quota
+	assert.Equal(t, 2, store.counters["quota:partn3r:month:2025-06"])
+	assert.False(t, nextMonth.Blocked)
+	assert.Equal(t, int64(1), nextMonth.Quotas[0].Remaining)
+}
+
+func TestVerify_ShapedServicesConsumeTheirQuotas(t *testing.T) {
+	// Arrange
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "batch", Type: "token", Key: "b4tch", Valid: true, AllowedRPS: 10, Shaping: true,
+		Quotas: []entity.QuotaConfig{{Period: entity.QuotaDay, Limit: 2}},
+	}}
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+	usecase.Clock = func() time.Time { return time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC) }
+	input := v.VerifyInputDTO{ApiKey: "b4tch"}
+
+	// Act
+	first := usecase.Verify(context.Background(), input)
+	second := usecase.Verify(context.Background(), input)
+	exhausted := usecase.Verify(context.Background(), input)
+	status := usecase.Status(context.Background(), input)
+
+	// Assert
+	assert.False(t, first.Blocked)
+	assert.Equal(t, int64(1), first.Quotas[0].Remaining)
+	assert.False(t, second.Blocked)
+	assert.Equal(t, http.StatusTooManyRequests, exhausted.Status)
+	assert.Equal(t, "quota_day", exhausted.LimitName)
+	assert.Equal(t, 2, store.counters["quota:b4tch:day:2025-06-10"])
+
+	quota := status.Limits[1]
+	assert.Equal(t, "quota_day", quota.Name)
+	assert.Equal(t, int64(2), quota.Used)
+	assert.Equal(t, int64(0), quota.Remaining)
+}
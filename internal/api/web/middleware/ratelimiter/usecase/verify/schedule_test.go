@@ -0,0 +1,110 @@
+package verify_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/repository"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStore serves a single config and keeps counters in memory
+type fakeStore struct {
+	repository.Store
+	config   entity.ServiceConfig
+	counters map[string]int
+	bytes    map[string]int64
+	// gets counts the lookups that may register the key
+	gets int
+}
+
+func (f *fakeStore) GetServiceRateLimit(key string) (entity.ServiceConfig, error) {
+	f.gets++
+	return f.config, nil
+}
+func (f *fakeStore) LookupServiceConfig(key string) (entity.ServiceConfig, bool, error) {
+	return f.config, !f.config.Unregistered, nil
+}
+func (f *fakeStore) IncrementRequestCount(key string, windowKey string, amount int) (int, error) {
+	f.counters[key+":"+windowKey] += amount
+	return f.counters[key+":"+windowKey], nil
+}
+func (f *fakeStore) IncrementRequestCounts(counters []repository.CounterRef, amount int) ([]int, error) {
+	totals := make([]int, len(counters))
+	for i, c := range counters {
+		f.counters[c.Key+":"+c.WindowKey] += amount
+		totals[i] = f.counters[c.Key+":"+c.WindowKey]
+	}
+	return totals, nil
+}
+func (f *fakeStore) GetRequestCounts(counters []repository.CounterRef) ([]int, error) {
+	totals := make([]int, len(counters))
+	for i, c := range counters {
+		totals[i] = f.counters[c.Key+":"+c.WindowKey]
+	}
+	return totals, nil
+}
+func (f *fakeStore) RefundRequestCount(key string, windowKey string, amount int) error {
+	f.counters[key+":"+windowKey] -= min(amount, f.counters[key+":"+windowKey])
+	return nil
+}
+func (f *fakeStore) GetByteCount(key string, windowKey string) (int64, error) {
+	return f.bytes[key+":"+windowKey], nil
+}
+func (f *fakeStore) AdjustByteCount(key string, windowKey string, delta int64, ttl time.Duration) (int64, error) {
+	if f.bytes == nil {
+		f.bytes = map[string]int64{}
+	}
+	f.bytes[key+":"+windowKey] += delta
+	return f.bytes[key+":"+windowKey], nil
+}
+func (f *fakeStore) ReserveShapingSlot(key string, cost int, interval time.Duration, burst int, maxDelay time.Duration, maxQueue int) (time.Duration, bool, error) {
+	return 0, true, nil
+}
+func (f *fakeStore) SetExpiration(key string, windowKey string, ttl time.Duration) error {
+	return nil
+}
+
+func TestVerify_AppliesTheActiveSchedule(t *testing.T) {
+	// Arrange
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "batch", Key: "b4tch", Valid: true, AllowedRPS: 1,
+		Timezone: "America/Sao_Paulo",
+		Schedules: []entity.ScheduleEntry{
+			{Name: "night", From: "22:00", To: "06:00", AllowedRPS: 3, Window: "1m"},
+		},
+	}}
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+	saoPaulo, _ := time.LoadLocation("America/Sao_Paulo")
+	input := v.VerifyInputDTO{ApiKey: "b4tch"}
+
+	verifyAt := func(at time.Time, times int) []v.VerifyOutputDTO {
+		usecase.Clock = func() time.Time { return at }
+		var outputs []v.VerifyOutputDTO
+		for i := 0; i < times; i++ {
+			outputs = append(outputs, usecase.Verify(context.Background(), input))
+		}
+		return outputs
+	}
+
+	// Act
+	// 02:30 on a Tuesday belongs to the range started on Monday night
+	night := verifyAt(time.Date(2025, 6, 3, 2, 30, 0, 0, saoPaulo), 4)
+	day := verifyAt(time.Date(2025, 6, 3, 14, 0, 0, 0, saoPaulo), 2)
+
+	// Assert
+	assert.Equal(t, "night", night[0].Schedule)
+	assert.Equal(t, 3, night[0].Limit)
+	assert.False(t, night[2].Blocked)
+	assert.Equal(t, http.StatusTooManyRequests, night[3].Status)
+	assert.Equal(t, "night", night[3].Schedule)
+
+	assert.Equal(t, "", day[0].Schedule)
+	assert.False(t, day[0].Blocked)
+	assert.True(t, day[1].Blocked)
+}
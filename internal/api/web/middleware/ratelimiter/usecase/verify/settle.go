@@ -0,0 +1,170 @@
+package verify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/repository"
+)
+
+// bytesWindowTTL keeps per-minute byte counters a little past their minute
+const bytesWindowTTL = 65 * time.Second
+
+// windowCounter adds costs to, and refunds them from, the window a request was
+// counted in
+type windowCounter struct {
+	v              *VerifyUsecase
+	config         entity.ServiceConfig
+	windowKey      string
+	ttl            time.Duration
+	bytesWindowKey string
+	// linked are the group, global and quota windows counted along with this one
+	linked []*linkedWindow
+	// shaped requests are paced by the leaky bucket and only have linked windows
+	shaped bool
+	// interval is the pace of the shaping slot a shaped request holds, given
+	// back along with its cost; zero until the slot is taken
+	interval time.Duration
+}
+
+func (v *VerifyUsecase) newWindowCounter(config entity.ServiceConfig, windowKey string, ttl time.Duration) *windowCounter {
+	return &windowCounter{v: v, config: config, windowKey: windowKey, ttl: ttl}
+}
+
+func (w *windowCounter) local() bool {
+	return w.config.SyncInterval() > 0 && w.v.LocalCounter != nil
+}
+
+// add charges amount to the window and to the linked windows, and returns the
+// new total of the window; the linked windows keep their own totals
+func (w *windowCounter) add(amount int) (int, error) {
+	refs := make([]repository.CounterRef, 0, len(w.linked)+1)
+	for _, s := range w.linked {
+		refs = append(refs, s.ref)
+	}
+
+	if w.shaped {
+		if len(refs) == 0 {
+			return 0, nil
+		}
+		totals, err := w.v.RateLimiterRepository.IncrementRequestCounts(refs, amount)
+		if err != nil {
+			return 0, err
+		}
+		w.setLinkedTotals(totals)
+		return 0, nil
+	}
+
+	if w.local() {
+		// Contagem aproximada: o contador local sincroniza com o repositório em lotes
+		count := w.v.LocalCounter.Increment(w.config.Key, w.windowKey, amount, w.config.SyncInterval(), w.ttl)
+		if len(refs) == 0 {
+			return count, nil
+		}
+		// Os contadores vinculados vão direto ao repositório
+		totals, err := w.v.RateLimiterRepository.IncrementRequestCounts(refs, amount)
+		if err != nil {
+			return count, err
+		}
+		w.setLinkedTotals(totals)
+		return count, nil
+	}
+
+	if len(refs) > 0 {
+		// Serviço, grupo, limite global e cotas são incrementados de forma atômica
+		own := repository.CounterRef{Key: w.config.Key, WindowKey: w.windowKey, TTL: w.ttl}
+		totals, err := w.v.RateLimiterRepository.IncrementRequestCounts(append([]repository.CounterRef{own}, refs...), amount)
+		if err != nil {
+			return 0, err
+		}
+		w.setLinkedTotals(totals[1:])
+		return totals[0], nil
+	}
+
+	count, err := w.v.RateLimiterRepository.IncrementRequestCount(w.config.Key, w.windowKey, amount)
+	if err != nil {
+		return count, err
+	}
+
+	// Aplicar TTL apenas se for a primeira requisição da janela
+	if count == amount {
+		_ = w.v.RateLimiterRepository.SetExpiration(w.config.Key, w.windowKey, w.ttl)
+	}
+	return count, nil
+}
+
+func (w *windowCounter) setLinkedTotals(totals []int) {
+	for i, s := range w.linked {
+		s.count = totals[i]
+	}
+}
+
+// refund gives amount back to the window and to the linked windows
+func (w *windowCounter) refund(amount int) error {
+	var err error
+	switch {
+	case w.shaped:
+		// O balde de shaping não tem janela própria: devolve o slot ocupado
+		if w.interval > 0 {
+			err = w.v.RateLimiterRepository.ReleaseShapingSlot(w.config.Key, amount, w.interval)
+		}
+	case w.local():
+		w.v.LocalCounter.Increment(w.config.Key, w.windowKey, -amount, w.config.SyncInterval(), w.ttl)
+	default:
+		err = w.v.RateLimiterRepository.RefundRequestCount(w.config.Key, w.windowKey, amount)
+	}
+	for _, s := range w.linked {
+		err = errors.Join(err, w.v.RateLimiterRepository.RefundRequestCount(s.ref.Key, s.ref.WindowKey, amount))
+	}
+	return err
+}
+
+// refundAdmitted gives amount back to the linked windows that only count
+// admitted requests (group, global limit and quotas) when the request is blocked
+func (w *windowCounter) refundAdmitted(amount int) {
+	for _, l := range w.linked {
+		if l.admittedOnly {
+			_ = w.v.RateLimiterRepository.RefundRequestCount(l.ref.Key, l.ref.WindowKey, amount)
+		}
+	}
+}
+
+// Settle commits, refunds or adjusts the quota reserved by Verify once the
+// response is known:
+//   - services with refund_server_errors get the cost of 5xx responses back;
+//   - a cost reported by the handler replaces the reserved one;
+//   - services with max_bytes_per_minute are charged the bytes served;
+//   - adaptive services learn the latency and status of the response.
+func (v *VerifyUsecase) Settle(ctx context.Context, verified VerifyOutputDTO, result SettleInputDTO) error {
+	// Alimentar o limite adaptativo com a latência e o status da resposta
+	if v.Adaptive != nil && !verified.Blocked {
+		v.Adaptive.Observe(verified.Key, result.Latency, result.Status)
+	}
+
+	w := verified.counter
+	if w == nil || verified.Blocked {
+		return nil
+	}
+
+	var errs []error
+
+	switch {
+	case w.config.RefundServerErrors && result.Status >= http.StatusInternalServerError:
+		errs = append(errs, w.refund(verified.Cost))
+	case result.Cost > verified.Cost:
+		_, err := w.add(result.Cost - verified.Cost)
+		errs = append(errs, err)
+	case result.Cost > 0 && result.Cost < verified.Cost:
+		errs = append(errs, w.refund(verified.Cost-result.Cost))
+	}
+
+	if w.config.MaxBytesPerMinute > 0 && w.bytesWindowKey != "" && result.Size > 0 {
+		_, err := v.RateLimiterRepository.AdjustByteCount(w.config.Key, w.bytesWindowKey, result.Size, bytesWindowTTL)
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
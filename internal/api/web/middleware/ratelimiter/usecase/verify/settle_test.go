@@ -0,0 +1,74 @@
+package verify_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettle_RefundsServerErrors(t *testing.T) {
+	// Arrange
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "flaky", Type: "token", Key: "f1aky", Valid: true, AllowedRPS: 2, RefundServerErrors: true,
+	}}
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+	usecase.Clock = func() time.Time { return time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC) }
+	input := v.VerifyInputDTO{ApiKey: "f1aky"}
+
+	// Act
+	var failures []v.VerifyOutputDTO
+	for range 5 {
+		out := usecase.Verify(context.Background(), input)
+		require.NoError(t, usecase.Settle(context.Background(), out, v.SettleInputDTO{Status: http.StatusInternalServerError}))
+		failures = append(failures, out)
+	}
+	succeeded := usecase.Verify(context.Background(), input)
+	require.NoError(t, usecase.Settle(context.Background(), succeeded, v.SettleInputDTO{Status: http.StatusOK}))
+	last := usecase.Verify(context.Background(), input)
+
+	// Assert
+	// Server errors are refunded, so the limit of 2 is never reached by them
+	for _, out := range failures {
+		assert.False(t, out.Blocked)
+		assert.Equal(t, 1, out.Count)
+	}
+	assert.False(t, succeeded.Blocked)
+	assert.False(t, last.Blocked)
+	assert.Equal(t, 2, last.Count)
+}
+
+func TestSettle_ChargesBytesAndVerifyReportsTheByteCap(t *testing.T) {
+	// Arrange
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "download", Type: "token", Key: "d0wn", Valid: true, AllowedRPS: 100, MaxBytesPerMinute: 10,
+	}}
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+	now := time.Date(2025, 6, 10, 12, 0, 30, 0, time.UTC)
+	usecase.Clock = func() time.Time { return now }
+	input := v.VerifyInputDTO{ApiKey: "d0wn"}
+
+	// Act
+	first := usecase.Verify(context.Background(), input)
+	require.NoError(t, usecase.Settle(context.Background(), first, v.SettleInputDTO{Status: http.StatusOK, Size: 10}))
+	refused := usecase.Verify(context.Background(), input)
+
+	// Assert
+	assert.False(t, first.Blocked)
+	assert.True(t, refused.Blocked)
+	assert.Equal(t, http.StatusTooManyRequests, refused.Status)
+	assert.Equal(t, "max_bytes_per_minute", refused.LimitName)
+	// The refusal reports the byte cap, not the request rate
+	assert.Equal(t, 10, refused.Limit)
+	assert.Equal(t, 0, refused.Remaining)
+	assert.Equal(t, time.Date(2025, 6, 10, 12, 1, 0, 0, time.UTC), refused.ResetAt.UTC())
+	// The refused request gave its cost back
+	assert.Equal(t, map[string]int{fmt.Sprintf("d0wn:%d", now.Unix()/100): 1}, store.counters)
+}
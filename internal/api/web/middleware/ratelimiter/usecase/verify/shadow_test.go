@@ -0,0 +1,90 @@
+package verify_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeShadow records the service each would-be block was reported for
+type fakeShadow struct {
+	services []string
+}
+
+func (f *fakeShadow) RecordShadowBlock(ctx context.Context, service string, blocked v.VerifyOutputDTO) {
+	f.services = append(f.services, service)
+}
+
+func TestVerify_ShadowBlocksOfUnknownKeysAreReportedForTheDefaultService(t *testing.T) {
+	// Arrange
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "service-x7Gq2", Type: "ip", Key: "203.0.113.7", Valid: true, AllowedRPS: 1,
+		Mode: entity.ModeShadow, Unregistered: true,
+	}}
+	shadow := &fakeShadow{}
+	usecase := v.NewVerifyUsecase(store, nil, shadow, nil, nil, nil, nil, nil)
+	usecase.Clock = func() time.Time { return time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC) }
+	input := v.VerifyInputDTO{ClientIp: "203.0.113.7"}
+
+	// Act
+	usecase.Verify(context.Background(), input)
+	second := usecase.Verify(context.Background(), input)
+
+	// Assert
+	assert.False(t, second.Blocked)
+	assert.Equal(t, []string{entity.DefaultServiceName}, shadow.services)
+}
+
+func TestVerify_ShadowServicesLetWouldBeBlocksThrough(t *testing.T) {
+	// Arrange
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "shadow-svc", Type: "token", Key: "sh4dow", Valid: true, AllowedRPS: 1, Mode: entity.ModeShadow,
+	}}
+	shadow := &fakeShadow{}
+	usecase := v.NewVerifyUsecase(store, nil, shadow, nil, nil, nil, nil, nil)
+	usecase.Clock = func() time.Time { return time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC) }
+
+	// Act
+	var outputs []v.VerifyOutputDTO
+	for range 3 {
+		outputs = append(outputs, usecase.Verify(context.Background(), v.VerifyInputDTO{ApiKey: "sh4dow"}))
+	}
+
+	// Assert
+	for _, out := range outputs {
+		assert.False(t, out.Blocked)
+	}
+	assert.Equal(t, []string{"shadow-svc", "shadow-svc"}, shadow.services)
+}
+
+func TestVerify_ShadowRoutesOnlyRelaxTheirOwnPaths(t *testing.T) {
+	// Arrange
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "shadow-route", Type: "token", Key: "r0ute", Valid: true, AllowedRPS: 1,
+		Routes: []entity.RouteRule{{Prefix: "/new", Cost: 1, Mode: entity.ModeShadow}},
+	}}
+	shadow := &fakeShadow{}
+	usecase := v.NewVerifyUsecase(store, nil, shadow, nil, nil, nil, nil, nil)
+	usecase.Clock = func() time.Time { return time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC) }
+	verify := func(path string) v.VerifyOutputDTO {
+		return usecase.Verify(context.Background(), v.VerifyInputDTO{ApiKey: "r0ute", Path: path})
+	}
+
+	// Act
+	first := verify("/old")
+	newRoute := verify("/new")
+	enforcedRoute := verify("/old")
+
+	// Assert
+	assert.False(t, first.Blocked)
+	assert.False(t, newRoute.Blocked)
+	assert.Equal(t, []string{"shadow-route"}, shadow.services)
+	assert.True(t, enforcedRoute.Blocked)
+	assert.Equal(t, http.StatusTooManyRequests, enforcedRoute.Status)
+}
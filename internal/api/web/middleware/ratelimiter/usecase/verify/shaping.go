@@ -0,0 +1,98 @@
+package verify
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/messages"
+)
+
+// shapingInterval spreads the limit over the window the service's counter
+// would use, so shaping admits as many requests per window as counting does
+func shapingInterval(window serviceWindow, limit int) time.Duration {
+	interval := window.size / time.Duration(limit)
+	if interval < time.Microsecond {
+		interval = time.Microsecond
+	}
+	return interval
+}
+
+// shape schedules the request on the service's leaky bucket, one slot every
+// interval, and waits for its slot, giving up if the caller's context is
+// cancelled first
+func (v *VerifyUsecase) shape(ctx context.Context, key string, input VerifyInputDTO, config entity.ServiceConfig, cost int, interval time.Duration) VerifyOutputDTO {
+	wait, ok, err := v.RateLimiterRepository.ReserveShapingSlot(
+		config.Key, cost, interval, config.AllowedRPS, config.ShapingMaxDelay(), config.MaxQueue,
+	)
+	if err != nil {
+		return VerifyOutputDTO{
+			Key:     key,
+			Name:    config.Name,
+			Blocked: true,
+			Message: message(input, config, messages.ShapingError, messages.Params{Service: config.Name}),
+			Status:  http.StatusInternalServerError,
+			Code:    messages.ShapingError,
+		}
+	}
+
+	now := v.Clock()
+	if !ok {
+		blocked := VerifyOutputDTO{
+			Key:     key,
+			Name:    config.Name,
+			Blocked: true,
+			Message: message(input, config, messages.QueueFull, messages.Params{
+				Service:  config.Name,
+				Limit:    int64(config.AllowedRPS),
+				MaxDelay: config.MaxDelay,
+			}),
+			Status:    http.StatusTooManyRequests,
+			Limit:     config.AllowedRPS,
+			ResetAt:   now.Add(wait),
+			Cost:      cost,
+			LimitName: "max_delay",
+			Code:      messages.QueueFull,
+		}
+		if v.enforce(ctx, input, config, blocked) {
+			return blocked
+		}
+		// Em modo shadow a requisição segue sem aguardar na fila
+		wait = 0
+	}
+
+	// Aguardar o slot da requisição no balde
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			// O slot reservado volta ao balde para quem está atrás na fila
+			if err := v.RateLimiterRepository.ReleaseShapingSlot(config.Key, cost, interval); err != nil {
+				v.Logger.Warn("falha ao devolver slot de shaping", "service", config.Name, "error", err)
+			}
+			return VerifyOutputDTO{
+				Key:     key,
+				Name:    config.Name,
+				Blocked: true,
+				Message: message(input, config, messages.QueueCancelled, messages.Params{Service: config.Name}),
+				Status:  http.StatusRequestTimeout,
+				Code:    messages.QueueCancelled,
+				Cost:    cost,
+			}
+		}
+	}
+
+	return VerifyOutputDTO{
+		Key:     key,
+		Name:    config.Name,
+		Blocked: false,
+		Status:  http.StatusOK,
+		Limit:   config.AllowedRPS,
+		ResetAt: now.Add(wait).Add(interval),
+		Cost:    cost,
+		Delay:   wait,
+	}
+}
@@ -0,0 +1,123 @@
+package verify_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shapingStore answers every reservation with wait and ok, and records the
+// reservations and releases the usecase asks for
+type shapingStore struct {
+	*fakeStore
+	wait      time.Duration
+	ok        bool
+	intervals []time.Duration
+	released  []int
+}
+
+func (s *shapingStore) ReserveShapingSlot(key string, cost int, interval time.Duration, burst int, maxDelay time.Duration, maxQueue int) (time.Duration, bool, error) {
+	s.intervals = append(s.intervals, interval)
+	return s.wait, s.ok, nil
+}
+func (s *shapingStore) ReleaseShapingSlot(key string, cost int, interval time.Duration) error {
+	s.released = append(s.released, cost)
+	return nil
+}
+
+func newShapingStore(wait time.Duration, ok bool) *shapingStore {
+	return &shapingStore{wait: wait, ok: ok, fakeStore: &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "batch", Type: "token", Key: "b4tch", Valid: true, AllowedRPS: 50, Shaping: true, MaxDelay: "30ms",
+	}}}
+}
+
+func TestVerify_ShapedRequestsWaitForTheirSlot(t *testing.T) {
+	// Arrange
+	store := newShapingStore(20*time.Millisecond, true)
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+
+	// Act
+	start := time.Now()
+	out := usecase.Verify(context.Background(), v.VerifyInputDTO{ApiKey: "b4tch"})
+	elapsed := time.Since(start)
+
+	// Assert
+	assert.False(t, out.Blocked)
+	assert.Equal(t, 20*time.Millisecond, out.Delay)
+	assert.GreaterOrEqual(t, elapsed, 20*time.Millisecond)
+	assert.Empty(t, store.released)
+}
+
+func TestVerify_ShapedRequestsAreRefusedWhenTheQueueIsFull(t *testing.T) {
+	// Arrange
+	store := newShapingStore(40*time.Millisecond, false)
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+
+	// Act
+	out := usecase.Verify(context.Background(), v.VerifyInputDTO{ApiKey: "b4tch"})
+
+	// Assert
+	assert.True(t, out.Blocked)
+	assert.Equal(t, http.StatusTooManyRequests, out.Status)
+	assert.Equal(t, "max_delay", out.LimitName)
+}
+
+func TestVerify_CancelledShapedRequestsGiveTheirSlotBack(t *testing.T) {
+	// Arrange
+	store := newShapingStore(20*time.Millisecond, true)
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Act
+	out := usecase.Verify(ctx, v.VerifyInputDTO{ApiKey: "b4tch", Cost: 2})
+
+	// Assert
+	assert.True(t, out.Blocked)
+	assert.Equal(t, http.StatusRequestTimeout, out.Status)
+	assert.Equal(t, []int{2}, store.released)
+}
+
+func TestVerify_ShapingPacesRequestsOverTheCounterWindow(t *testing.T) {
+	// Arrange
+	store := newShapingStore(0, true)
+	store.config.Schedules = []entity.ScheduleEntry{{Name: "night", From: "22:00", To: "06:00", AllowedRPS: 3, Window: "1m"}}
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+	verifyAt := func(at time.Time) {
+		usecase.Clock = func() time.Time { return at }
+		usecase.Verify(context.Background(), v.VerifyInputDTO{ApiKey: "b4tch"})
+	}
+
+	// Act
+	verifyAt(time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC))
+	verifyAt(time.Date(2025, 6, 10, 23, 0, 0, 0, time.UTC))
+
+	// Assert
+	// 50 requests per 50s window, then 3 requests per minute at night
+	assert.Equal(t, []time.Duration{time.Second, 20 * time.Second}, store.intervals)
+}
+
+func TestSettle_RefundedShapedRequestsGiveTheirSlotBack(t *testing.T) {
+	// Arrange
+	store := newShapingStore(0, true)
+	store.config.RefundServerErrors = true
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+	failed := usecase.Verify(context.Background(), v.VerifyInputDTO{ApiKey: "b4tch", Cost: 3})
+	succeeded := usecase.Verify(context.Background(), v.VerifyInputDTO{ApiKey: "b4tch"})
+
+	// Act
+	failedErr := usecase.Settle(context.Background(), failed, v.SettleInputDTO{Status: http.StatusBadGateway})
+	succeededErr := usecase.Settle(context.Background(), succeeded, v.SettleInputDTO{Status: http.StatusOK})
+
+	// Assert
+	require.NoError(t, failedErr)
+	require.NoError(t, succeededErr)
+	assert.Equal(t, []int{3}, store.released)
+}
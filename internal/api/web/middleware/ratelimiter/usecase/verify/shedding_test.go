@@ -0,0 +1,49 @@
+package verify_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/shedding"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify_ShedsLowPriorityTrafficWhileRequestsAreInFlight(t *testing.T) {
+	// Arrange
+	keys := map[string]entity.ServiceConfig{
+		"fr33": {Name: "free", Type: "token", Key: "fr33", Valid: true, AllowedRPS: 100},
+		"pa1d": {Name: "paid", Type: "token", Key: "pa1d", Valid: true, AllowedRPS: 100, Priority: 2},
+	}
+	store := &fakeStore{counters: map[string]int{}}
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	// Free traffic is shed from 1 request in flight, paid only at 2
+	shedFrom := 0.5
+	shedder := shedding.NewController(entity.SheddingConfig{MaxInFlight: 2, ShedFrom: &shedFrom, PriorityStep: 0.25, RetryAfter: "3s"}, clock)
+	usecase := v.NewVerifyUsecase(store, nil, nil, shedder, nil, nil, nil, nil)
+	usecase.Clock = clock
+	verify := func(key string) v.VerifyOutputDTO {
+		store.config = keys[key]
+		return usecase.Verify(context.Background(), v.VerifyInputDTO{ApiKey: key, TrackInFlight: true})
+	}
+
+	// Act
+	inFlight := verify("pa1d")
+	shed := verify("fr33")
+	paid := verify("pa1d")
+	paid.Release()
+	inFlight.Release()
+	afterLoad := verify("fr33")
+
+	// Assert
+	assert.True(t, shed.Blocked)
+	assert.Equal(t, http.StatusServiceUnavailable, shed.Status)
+	assert.True(t, shed.ResetAt.Equal(now.Add(3*time.Second)))
+	assert.False(t, paid.Blocked)
+	assert.False(t, afterLoad.Blocked)
+}
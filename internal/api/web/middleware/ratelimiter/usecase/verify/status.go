@@ -0,0 +1,178 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/messages"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/repository"
+)
+
+// Status reports the usage of every limit of the service the caller's API key
+// belongs to. It only reads the counters, so it never consumes quota; counts
+// still held by the local counter are not seen until they are synced.
+func (v *VerifyUsecase) Status(ctx context.Context, input VerifyInputDTO) StatusOutputDTO {
+	if input.ApiKey == "" {
+		return statusRefusal(input, entity.ServiceConfig{}, http.StatusUnauthorized, messages.InvalidAPIKey)
+	}
+
+	// Consulta somente leitura: chaves desconhecidas não ganham uma config
+	config, ok, err := v.RateLimiterRepository.LookupServiceConfig(input.ApiKey)
+	if err != nil {
+		return statusRefusal(input, config, http.StatusInternalServerError, messages.ConfigError)
+	}
+	if !ok || config.Type != "token" {
+		return statusRefusal(input, entity.ServiceConfig{}, http.StatusUnauthorized, messages.InvalidAPIKey)
+	}
+
+	now := v.Clock()
+	window := currentWindow(config, now)
+	config.AllowedRPS = window.limit
+	if config.Adaptive.Enabled && v.Adaptive != nil {
+		config.AllowedRPS = v.adaptiveLimit(config)
+	}
+	shaping := config.Shaping && config.AllowedRPS > 0
+	linked := linkedWindows(config, now)
+
+	// Uma única leitura para a janela do serviço e as vinculadas
+	refs := make([]repository.CounterRef, 0, len(linked)+1)
+	for _, l := range linked {
+		refs = append(refs, l.ref)
+	}
+	if !shaping {
+		refs = append(refs, repository.CounterRef{Key: config.Key, WindowKey: window.key})
+	}
+	counts, err := v.RateLimiterRepository.GetRequestCounts(refs)
+	if err != nil {
+		return statusRefusal(input, config, http.StatusInternalServerError, messages.CounterError)
+	}
+
+	out := StatusOutputDTO{Name: config.Name}
+	// Sem bloqueio explícito, o bloqueio ativo é o do limite esgotado que renova por último
+	exhausted := func(code, text string, until time.Time) {
+		if out.Block == nil || until.After(out.Block.Until) {
+			out.Block = &BlockStatus{Code: code, Message: text, Until: until}
+		}
+	}
+
+	own := LimitStatus{
+		Name:     "allowed_rps",
+		Level:    LevelKey,
+		Limit:    int64(config.AllowedRPS),
+		Schedule: window.schedule,
+		Shaping:  shaping,
+	}
+	if shaping {
+		own.Remaining = own.Limit
+	} else {
+		own.Used = int64(counts[len(linked)])
+		own.Remaining = max(own.Limit-own.Used, 0)
+		own.ResetAt = window.resetAt
+		if own.Remaining == 0 {
+			exhausted(messages.RateLimited, message(input, config, messages.RateLimited, messages.Params{
+				Service: config.Name,
+				Limit:   own.Limit,
+				Reset:   window.resetAt.Format("15:04:05"),
+			}), window.resetAt)
+		}
+	}
+	out.Limits = append(out.Limits, own)
+
+	for i, l := range linked {
+		if l.limit <= 0 {
+			// Serviços com bypass são contados no limite global sem serem limitados
+			continue
+		}
+		l.count = counts[i]
+		blocked := l.blocked(config.Key, input, config)
+		status := LimitStatus{
+			Name:      blocked.LimitName,
+			Level:     l.level,
+			Limit:     int64(l.limit),
+			Used:      int64(l.count),
+			Remaining: int64(max(l.limit-l.count, 0)),
+			ResetAt:   l.resetAt,
+		}
+		if status.Remaining == 0 {
+			exhausted(blocked.Code, blocked.Message, l.resetAt)
+		}
+		out.Limits = append(out.Limits, status)
+	}
+
+	if config.MaxBytesPerMinute > 0 {
+		minute := now.Unix() / 60
+		usedBytes, err := v.RateLimiterRepository.GetByteCount(config.Key, fmt.Sprintf("%d", minute))
+		if err != nil {
+			return statusRefusal(input, config, http.StatusInternalServerError, messages.ByteCounterError)
+		}
+		minuteResetAt := time.Unix((minute+1)*60, 0)
+		status := LimitStatus{
+			Name:      "max_bytes_per_minute",
+			Limit:     config.MaxBytesPerMinute,
+			Used:      usedBytes,
+			Remaining: max(config.MaxBytesPerMinute-usedBytes, 0),
+			ResetAt:   minuteResetAt,
+		}
+		if status.Remaining == 0 {
+			exhausted(messages.ByteLimitExceeded, message(input, config, messages.ByteLimitExceeded, messages.Params{
+				Service: config.Name,
+				Limit:   config.MaxBytesPerMinute,
+				Reset:   minuteResetAt.Format("15:04:05"),
+			}), minuteResetAt)
+		}
+		out.Limits = append(out.Limits, status)
+	}
+
+	if config.MaxConcurrent > 0 {
+		// Slots ocupados são liberados ao fim de cada requisição, não bloqueiam a chave
+		inFlight, err := v.RateLimiterRepository.CountSlots(config.Key)
+		if err != nil {
+			return statusRefusal(input, config, http.StatusInternalServerError, messages.SlotError)
+		}
+		out.Limits = append(out.Limits, LimitStatus{
+			Name:      "max_concurrent",
+			Limit:     int64(config.MaxConcurrent),
+			Used:      int64(inFlight),
+			Remaining: int64(max(config.MaxConcurrent-inFlight, 0)),
+		})
+	}
+
+	// O bloqueio explícito do serviço prevalece e só termina mudando a config
+	if !config.Valid {
+		out.Block = &BlockStatus{
+			Code:    messages.ServiceBlocked,
+			Message: message(input, config, messages.ServiceBlocked, messages.Params{Service: config.Name}),
+		}
+	}
+
+	return out
+}
+
+// adaptiveLimit returns the effective limit of an adaptive service without
+// counting the call as traffic, or its configured limit if it was not seen yet
+func (v *VerifyUsecase) adaptiveLimit(config entity.ServiceConfig) int {
+	if limit, ok := v.Adaptive.Effective(config.Key); ok {
+		return limit
+	}
+	return config.AllowedRPS
+}
+
+// statusRefusal builds the output of a status request that cannot be answered
+func statusRefusal(input VerifyInputDTO, config entity.ServiceConfig, status int, code string) StatusOutputDTO {
+	return StatusOutputDTO{
+		Name: config.Name,
+		Refusal: VerifyOutputDTO{
+			Key:         input.ApiKey,
+			Name:        config.Name,
+			Blocked:     true,
+			Message:     message(input, config, code, messages.Params{Service: config.Name}),
+			Status:      status,
+			Code:        code,
+			ErrorFormat: config.ErrorFormat,
+			ProblemType: config.ProblemType(code),
+		},
+	}
+}
@@ -0,0 +1,82 @@
+package verify_test
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/messages"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus_ReportsUsageWithoutConsumingQuota(t *testing.T) {
+	// Arrange
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "partner", Type: "token", Key: "partn3r", Valid: true, AllowedRPS: 2,
+		Quotas: []entity.QuotaConfig{{Period: entity.QuotaDay, Limit: 5}},
+	}}
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	usecase.Clock = func() time.Time { return now }
+	input := v.VerifyInputDTO{ApiKey: "partn3r"}
+	for i := 0; i < 3; i++ {
+		usecase.Verify(context.Background(), input)
+	}
+	counters := maps.Clone(store.counters)
+
+	// Act
+	status := usecase.Status(context.Background(), input)
+	again := usecase.Status(context.Background(), input)
+
+	// Assert
+	assert.False(t, status.Refusal.Blocked)
+	assert.Equal(t, "partner", status.Name)
+	assert.Len(t, status.Limits, 2)
+
+	own := status.Limits[0]
+	assert.Equal(t, "allowed_rps", own.Name)
+	// The service's own window also counts the blocked request
+	assert.Equal(t, int64(3), own.Used)
+	assert.Equal(t, int64(0), own.Remaining)
+	assert.True(t, own.ResetAt.Equal(now.Add(2*time.Second)))
+
+	quota := status.Limits[1]
+	assert.Equal(t, "quota_day", quota.Name)
+	assert.Equal(t, int64(2), quota.Used)
+	assert.Equal(t, int64(3), quota.Remaining)
+	assert.True(t, quota.ResetAt.Equal(time.Date(2025, 6, 11, 0, 0, 0, 0, time.UTC)))
+
+	assert.Equal(t, messages.RateLimited, status.Block.Code)
+	assert.True(t, status.Block.Until.Equal(own.ResetAt))
+
+	// Reading the status did not count as a request
+	assert.Equal(t, counters, store.counters)
+	assert.Equal(t, status, again)
+}
+
+func TestStatus_RefusesKeysWithoutAService(t *testing.T) {
+	// Arrange
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		// Cópia da config default guardada por um Verify anterior da chave
+		Name: "service-x1", Type: "ip", Key: "guess", Valid: true, AllowedRPS: 10, Unregistered: true,
+	}}
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, nil, nil, nil)
+
+	// Act
+	missing := usecase.Status(context.Background(), v.VerifyInputDTO{})
+	unknown := usecase.Status(context.Background(), v.VerifyInputDTO{ApiKey: "guess"})
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, missing.Refusal.Status)
+	assert.Equal(t, messages.InvalidAPIKey, missing.Refusal.Code)
+	assert.Equal(t, http.StatusUnauthorized, unknown.Refusal.Status)
+	assert.Empty(t, unknown.Limits)
+	assert.Empty(t, unknown.Name)
+	// The status lookup never registers unknown keys
+	assert.Zero(t, store.gets)
+}
@@ -0,0 +1,51 @@
+package verify_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usage"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUsageStore keeps the records flushed by the aggregator
+type fakeUsageStore struct {
+	records []entity.UsageRecord
+}
+
+func (f *fakeUsageStore) AddUsage(records []entity.UsageRecord) error {
+	f.records = append(f.records, records...)
+	return nil
+}
+func (f *fakeUsageStore) GetUsage(from, to time.Time) ([]entity.UsageRecord, error) {
+	return f.records, nil
+}
+
+func TestVerify_UsageOfUnknownKeysIsRecordedForTheDefaultService(t *testing.T) {
+	// Arrange
+	store := &fakeStore{counters: map[string]int{}, config: entity.ServiceConfig{
+		Name: "service-x7Gq2", Type: "ip", Key: "203.0.113.7", Valid: true, AllowedRPS: 1, Unregistered: true,
+	}}
+	usageStore := &fakeUsageStore{}
+	aggregator := usage.NewAggregator(usageStore, nil)
+	usecase := v.NewVerifyUsecase(store, nil, nil, nil, nil, aggregator, nil, nil)
+	hour := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	usecase.Clock = func() time.Time { return hour }
+	input := v.VerifyInputDTO{ClientIp: "203.0.113.7"}
+
+	// Act
+	usecase.Verify(context.Background(), input)
+	usecase.Verify(context.Background(), input)
+	err := aggregator.Flush()
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []entity.UsageRecord{
+		{Hour: hour, Service: entity.DefaultServiceName, Admitted: 1, Rejected: 1},
+	}, usageStore.records)
+}
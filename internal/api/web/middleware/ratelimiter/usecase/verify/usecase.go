@@ -3,22 +3,81 @@ package verify
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
-	"ratelim/internal/api/web/middleware/ratelimiter/repository"
 	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/adaptive"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/localcounter"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/messages"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/repository"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/shedding"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usage"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/infra/logging"
 )
 
 type VerifyUsecase struct {
 	RateLimiterRepository repository.Store
+	LocalCounter          *localcounter.Counter
+	ShadowRecorder        ShadowRecorder
+	Shedder               *shedding.Controller
+	Adaptive              *adaptive.Controller
+	Usage                 *usage.Aggregator
+	Audit                 AuditRecorder
+	Logger                *slog.Logger
+	// Clock tells the current time of every decision; replace it in tests
+	Clock func() time.Time
 }
 
-func NewVerifyUsecase(rateLimiterRepository repository.Store) *VerifyUsecase {
+// NewVerifyUsecase builds the usecase. localCounter may be nil, in which case
+// services with local_sync_interval are counted directly in the repository,
+// shadowRecorder may be nil to drop would-be blocks of shadow mode limits,
+// shedder may be nil to never shed load, adaptiveLimits may be nil to keep
+// adaptive services at their configured allowed_rps, usageAggregator may be nil
+// to not keep hourly usage for billing, auditRecorder may be nil to not audit
+// refused requests, and logger may be nil to log to slog.Default().
+func NewVerifyUsecase(rateLimiterRepository repository.Store, localCounter *localcounter.Counter, shadowRecorder ShadowRecorder, shedder *shedding.Controller, adaptiveLimits *adaptive.Controller, usageAggregator *usage.Aggregator, auditRecorder AuditRecorder, logger *slog.Logger) *VerifyUsecase {
 	return &VerifyUsecase{
 		RateLimiterRepository: rateLimiterRepository,
+		LocalCounter:          localCounter,
+		ShadowRecorder:        shadowRecorder,
+		Shedder:               shedder,
+		Adaptive:              adaptiveLimits,
+		Usage:                 usageAggregator,
+		Audit:                 auditRecorder,
+		Logger:                logging.OrDefault(logger),
+		Clock:                 time.Now,
 	}
 }
 
 func (v *VerifyUsecase) Verify(ctx context.Context, input VerifyInputDTO) VerifyOutputDTO {
+	var config entity.ServiceConfig
+	decision := v.verify(ctx, input, &config)
+	now := v.Clock()
+
+	// Formato do corpo da recusa definido pela configuração do serviço
+	if decision.Blocked {
+		decision.ErrorFormat = config.ErrorFormat
+		decision.ProblemType = config.ProblemType(decision.Code)
+	}
+
+	// Agregar o uso para faturamento; erros internos não são decisões sobre o cliente.
+	// Chaves desconhecidas somam no serviço default, não nos nomes gerados para elas.
+	if v.Usage != nil && decision.Name != "" && decision.Status != http.StatusInternalServerError {
+		v.Usage.Record(config.ConfiguredName(), now, !decision.Blocked)
+	}
+
+	// Registrar o motivo de toda recusa na trilha de auditoria
+	v.audit(ctx, input, decision, now)
+
+	v.logDecision(ctx, decision)
+
+	return decision
+}
+
+// verify decides on the request and stores the config of the caller's service in resolved
+func (v *VerifyUsecase) verify(ctx context.Context, input VerifyInputDTO, resolved *entity.ServiceConfig) VerifyOutputDTO {
 	// Obter chave de rate limit
 	var key string
 	if input.ApiKey == "" {
@@ -29,13 +88,15 @@ func (v *VerifyUsecase) Verify(ctx context.Context, input VerifyInputDTO) Verify
 
 	// Obter config de rate limit do repositório
 	config, err := v.RateLimiterRepository.GetServiceRateLimit(key)
+	*resolved = config
 	if err != nil {
 		return VerifyOutputDTO{
 			Key:     key,
 			Name:    config.Name,
 			Blocked: true,
-			Message: "Erro ao buscar configuração de rate limit",
+			Message: message(input, config, messages.ConfigError, messages.Params{}),
 			Status:  http.StatusInternalServerError,
+			Code:    messages.ConfigError,
 		}
 	}
 
@@ -45,8 +106,30 @@ func (v *VerifyUsecase) Verify(ctx context.Context, input VerifyInputDTO) Verify
 			Key:     key,
 			Name:    config.Name,
 			Blocked: true,
-			Message: "Serviço bloqueado",
+			Message: message(input, config, messages.ServiceBlocked, messages.Params{Service: config.Name}),
 			Status:  http.StatusForbidden,
+			Code:    messages.ServiceBlocked,
+		}
+	}
+
+	now := v.Clock()
+
+	// Descartar tráfego de menor prioridade quando a instância está sobrecarregada
+	if v.Shedder != nil && !v.Shedder.Admit(config.Priority) {
+		retryAfter := v.Shedder.RetryAfter()
+		return VerifyOutputDTO{
+			Key:     key,
+			Name:    config.Name,
+			Blocked: true,
+			Message: message(input, config, messages.Overloaded, messages.Params{
+				Service:    config.Name,
+				Priority:   config.Priority,
+				RetryAfter: retryAfter.String(),
+			}),
+			Status:    http.StatusServiceUnavailable,
+			ResetAt:   now.Add(retryAfter),
+			LimitName: "priority",
+			Code:      messages.Overloaded,
 		}
 	}
 
@@ -56,57 +139,270 @@ func (v *VerifyUsecase) Verify(ctx context.Context, input VerifyInputDTO) Verify
 		windowSeconds = 60 // fallback seguro se não estiver configurado corretamente
 	}
 
-	now := time.Now()
-	windowSize := int64(config.AllowedRPS)
-	if windowSize == 0 {
-		windowSize = 60 // segurança
+	// Faixas de horário substituem o limite e a janela enquanto ativas
+	window := currentWindow(config, now)
+	config.AllowedRPS = window.limit
+	scheduleName := window.schedule
+
+	// Serviços adaptativos usam o limite efetivo, que acompanha a saúde do
+	// backend; a janela continua definida pelo allowed_rps configurado
+	if config.Adaptive.Enabled && v.Adaptive != nil {
+		config.AllowedRPS = v.Adaptive.Limit(key, config)
 	}
-	windowTimestamp := now.Unix() / windowSize
-	windowKey := fmt.Sprintf("%d", windowTimestamp)
 
-	// Incrementar contador
-	count, err := v.RateLimiterRepository.IncrementRequestCount(config.Key, windowKey)
-	if err != nil {
-		return VerifyOutputDTO{
-			Key:     key,
-			Name:    config.Name,
-			Blocked: true,
-			Message: "Erro interno ao contar requisições",
-			Status:  http.StatusInternalServerError,
+	// Custo da requisição: definido pelo chamador ou pela regra de rota
+	cost := input.Cost
+	if cost <= 0 {
+		cost = config.CostFor(input.Method, input.Path)
+	}
+
+	// Serviços com shaping aguardam o seu slot em vez de serem bloqueados
+	if config.Shaping && config.AllowedRPS > 0 {
+		// O balde substitui a janela do serviço, mas grupo, limite global e
+		// cotas valem igualmente e são verificados antes de ocupar um slot
+		counter := v.newWindowCounter(config, "", 0)
+		counter.shaped = true
+		counter.linked = linkedWindows(config, now)
+		if _, err := counter.add(cost); err != nil {
+			return counterError(key, input, config)
 		}
+		if blocked, ok := v.blockedByLinked(ctx, key, input, config, counter); ok {
+			_ = counter.refund(cost)
+			return blocked
+		}
+
+		interval := shapingInterval(window, config.AllowedRPS)
+		shaped := v.shape(ctx, key, input, config, cost, interval)
+		shaped.Schedule = scheduleName
+		shaped.Quotas = quotaUsages(counter.linked)
+		if shaped.Blocked {
+			_ = counter.refund(cost)
+			return shaped
+		}
+		// A partir daqui a requisição ocupa um slot, devolvido junto com o custo
+		counter.interval = interval
+		shaped.counter = counter
+		return v.trackInFlight(input, v.holdSlot(ctx, input, config, shaped))
+	}
+
+	// Incrementar contador (reserva o custo; Settle pode ajustá-lo depois)
+	counter := v.newWindowCounter(config, window.key, window.ttl)
+	counter.linked = linkedWindows(config, now)
+	count, err := counter.add(cost)
+	if err != nil {
+		return counterError(key, input, config)
 	}
 
-	// Aplicar TTL apenas se for a primeira requisição da janela
-	if count == 1 {
-		ttl := time.Duration(windowSize+5) * time.Second
-		_ = v.RateLimiterRepository.SetExpiration(config.Key, windowKey, ttl)
+	windowResetAt := window.resetAt
+	remaining := config.AllowedRPS - count
+	for _, s := range counter.linked {
+		if s.limit > 0 && s.level != LevelQuota {
+			remaining = min(remaining, s.limit-s.count)
+		}
+	}
+	if remaining < 0 {
+		remaining = 0
 	}
 
 	// Verificar se está bloqueado
 	if count > config.AllowedRPS {
-		// Calcular quando o bloqueio termina
-		windowResetAt := time.Unix((windowTimestamp+1)*windowSize, 0)
-		msg := fmt.Sprintf(
-			"Rate limit excedido para o serviço '%s': %d requisições permitidas por segundo. Bloqueado até %s.",
-			config.Name,
-			config.AllowedRPS,
-			windowResetAt.Format("15:04:05"),
-		)
-		return VerifyOutputDTO{
+		blocked := VerifyOutputDTO{
 			Key:     key,
 			Name:    config.Name,
 			Blocked: true,
-			Message: msg,
-			Status:  http.StatusTooManyRequests,
+			Message: message(input, config, messages.RateLimited, messages.Params{
+				Service: config.Name,
+				Limit:   int64(config.AllowedRPS),
+				Reset:   windowResetAt.Format("15:04:05"),
+			}),
+			Status:    http.StatusTooManyRequests,
+			Limit:     config.AllowedRPS,
+			Remaining: remaining,
+			ResetAt:   windowResetAt,
+			Count:     count,
+			LimitName: "allowed_rps",
+			Level:     LevelKey,
+			Code:      messages.RateLimited,
+			Schedule:  scheduleName,
+			Quotas:    quotaUsages(counter.linked),
+		}
+		if v.enforce(ctx, input, config, blocked) {
+			counter.refundAdmitted(cost)
+			return blocked
+		}
+	}
+
+	// Verificar os limites vinculados: grupo do serviço, limite global e cotas
+	if blocked, ok := v.blockedByLinked(ctx, key, input, config, counter); ok {
+		counter.refundAdmitted(cost)
+		return blocked
+	}
+
+	// Verificar a cota de bytes do minuto atual
+	if config.MaxBytesPerMinute > 0 {
+		minute := now.Unix() / 60
+		counter.bytesWindowKey = fmt.Sprintf("%d", minute)
+		usedBytes, err := v.RateLimiterRepository.GetByteCount(config.Key, counter.bytesWindowKey)
+		if err != nil {
+			return VerifyOutputDTO{
+				Key:     key,
+				Name:    config.Name,
+				Blocked: true,
+				Message: message(input, config, messages.ByteCounterError, messages.Params{Service: config.Name}),
+				Status:  http.StatusInternalServerError,
+				Code:    messages.ByteCounterError,
+			}
+		}
+		if usedBytes >= config.MaxBytesPerMinute {
+			minuteResetAt := time.Unix((minute+1)*60, 0)
+			blocked := VerifyOutputDTO{
+				Key:     key,
+				Name:    config.Name,
+				Blocked: true,
+				Message: message(input, config, messages.ByteLimitExceeded, messages.Params{
+					Service: config.Name,
+					Limit:   config.MaxBytesPerMinute,
+					Reset:   minuteResetAt.Format("15:04:05"),
+				}),
+				Status:    http.StatusTooManyRequests,
+				Limit:     int(config.MaxBytesPerMinute),
+				Remaining: int(max(config.MaxBytesPerMinute-usedBytes, 0)),
+				ResetAt:   minuteResetAt,
+				Count:     int(usedBytes),
+				LimitName: "max_bytes_per_minute",
+				Code:      messages.ByteLimitExceeded,
+			}
+			if v.enforce(ctx, input, config, blocked) {
+				_ = counter.refund(cost)
+				return blocked
+			}
 		}
 	}
 
 	// Requisição liberada
+	return v.trackInFlight(input, v.holdSlot(ctx, input, config, VerifyOutputDTO{
+		Key:       key,
+		Name:      config.Name,
+		Blocked:   false,
+		Message:   "",
+		Status:    http.StatusOK,
+		Limit:     config.AllowedRPS,
+		Remaining: remaining,
+		ResetAt:   windowResetAt,
+		Cost:      cost,
+		Count:     count,
+		Schedule:  scheduleName,
+		Quotas:    quotaUsages(counter.linked),
+		counter:   counter,
+	}))
+}
+
+// blockedByLinked returns the refusal of the first linked window the request
+// exceeded, if it is enforced
+func (v *VerifyUsecase) blockedByLinked(ctx context.Context, key string, input VerifyInputDTO, config entity.ServiceConfig, counter *windowCounter) (VerifyOutputDTO, bool) {
+	for _, s := range counter.linked {
+		if !s.exceeded() {
+			continue
+		}
+		blocked := s.blocked(key, input, config)
+		blocked.Quotas = quotaUsages(counter.linked)
+		if v.enforce(ctx, input, config, blocked) {
+			return blocked, true
+		}
+	}
+	return VerifyOutputDTO{}, false
+}
+
+// counterError is the refusal of a request whose counters could not be updated
+func counterError(key string, input VerifyInputDTO, config entity.ServiceConfig) VerifyOutputDTO {
 	return VerifyOutputDTO{
 		Key:     key,
 		Name:    config.Name,
-		Blocked: false,
-		Message: "",
-		Status:  http.StatusOK,
+		Blocked: true,
+		Message: message(input, config, messages.CounterError, messages.Params{Service: config.Name}),
+		Status:  http.StatusInternalServerError,
+		Code:    messages.CounterError,
+	}
+}
+
+// enforce reports whether blocked must be applied. When the request's limits
+// run in shadow mode the block is recorded and the request let through instead.
+func (v *VerifyUsecase) enforce(ctx context.Context, input VerifyInputDTO, config entity.ServiceConfig, blocked VerifyOutputDTO) bool {
+	if !config.ShadowFor(input.Method, input.Path) {
+		return true
+	}
+	if v.ShadowRecorder != nil {
+		v.ShadowRecorder.RecordShadowBlock(ctx, config.ConfiguredName(), blocked)
+	}
+	return false
+}
+
+// logDecision logs internal errors at error level and every other decision at
+// debug level, along with the request attributes found in ctx. Refusals are
+// routine under load; the audit trail is where they are kept.
+func (v *VerifyUsecase) logDecision(ctx context.Context, decision VerifyOutputDTO) {
+	level := slog.LevelDebug
+	if decision.Status == http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	if !v.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("service", decision.Name),
+		slog.String("key_fingerprint", entity.KeyFingerprint(decision.Key)),
+		slog.String("decision", Decision(decision)),
+		slog.Int("status", decision.Status),
+	}
+	if decision.Blocked {
+		attrs = append(attrs, slog.String("limit", decision.LimitName), slog.String("detail", decision.Message))
+	}
+	v.Logger.LogAttrs(ctx, level, "decisão de rate limit", attrs...)
+}
+
+// Decision names the outcome of a Verify call for logs: "allowed" or "blocked"
+func Decision(decision VerifyOutputDTO) string {
+	if decision.Blocked {
+		return "blocked"
+	}
+	return "allowed"
+}
+
+// serviceWindow is the window the service's own counter is kept in
+type serviceWindow struct {
+	key      string
+	size     time.Duration
+	ttl      time.Duration
+	resetAt  time.Time
+	limit    int
+	schedule string
+}
+
+// currentWindow returns the service's window at now: allowed_rps requests per
+// window of allowed_rps seconds, unless a schedule entry is active
+func currentWindow(config entity.ServiceConfig, now time.Time) serviceWindow {
+	window := serviceWindow{limit: config.AllowedRPS}
+	windowSize := int64(config.AllowedRPS)
+
+	// Faixas de horário substituem o limite e a janela enquanto ativas
+	if schedule := config.ActiveSchedule(now); schedule != nil {
+		window.limit = schedule.AllowedRPS
+		window.schedule = schedule.Name
+		windowSize = schedule.WindowSeconds()
+	}
+
+	if windowSize == 0 {
+		windowSize = 60 // segurança
+	}
+	windowTimestamp := now.Unix() / windowSize
+	window.key = fmt.Sprintf("%d", windowTimestamp)
+	if window.schedule != "" {
+		// Cada faixa conta em janelas próprias
+		window.key = fmt.Sprintf("%s:%d", window.schedule, windowTimestamp)
 	}
+	window.size = time.Duration(windowSize) * time.Second
+	window.ttl = time.Duration(windowSize+5) * time.Second
+	window.resetAt = time.Unix((windowTimestamp+1)*windowSize, 0)
+	return window
 }
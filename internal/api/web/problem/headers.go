@@ -0,0 +1,52 @@
+package problem
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+)
+
+const (
+	HeaderLimit      = "X-Ratelimit-Limit"
+	HeaderRemaining  = "X-Ratelimit-Remaining"
+	HeaderReset      = "X-Ratelimit-Reset"
+	HeaderRetryAfter = "Retry-After"
+	HeaderSchedule   = "X-Ratelimit-Schedule"
+
+	// HeaderQuotaPrefix starts the headers of each calendar quota, e.g.
+	// X-Ratelimit-Quota-Month-Remaining
+	HeaderQuotaPrefix = "X-Ratelimit-Quota-"
+)
+
+// SetHeaders describes the caller's current window, schedule and quotas on
+// the response, and tells blocked callers when to retry
+func SetHeaders(h http.Header, block v.VerifyOutputDTO, now time.Time) {
+	if block.Schedule != "" {
+		h.Set(HeaderSchedule, block.Schedule)
+	}
+
+	for _, q := range block.Quotas {
+		prefix := HeaderQuotaPrefix + strings.ToUpper(q.Period[:1]) + q.Period[1:] + "-"
+		h.Set(prefix+"Limit", strconv.FormatInt(q.Limit, 10))
+		h.Set(prefix+"Remaining", strconv.FormatInt(q.Remaining, 10))
+		h.Set(prefix+"Reset", strconv.FormatInt(q.ResetAt.Unix(), 10))
+	}
+
+	if block.ResetAt.IsZero() {
+		return
+	}
+
+	// Requisições descartadas por sobrecarga não têm janela, apenas Retry-After
+	if block.Limit > 0 {
+		h.Set(HeaderLimit, strconv.Itoa(block.Limit))
+		h.Set(HeaderRemaining, strconv.Itoa(block.Remaining))
+		h.Set(HeaderReset, strconv.FormatInt(block.ResetAt.Unix(), 10))
+	}
+
+	if block.Blocked {
+		h.Set(HeaderRetryAfter, strconv.Itoa(RetryAfter(block.ResetAt, now)))
+	}
+}
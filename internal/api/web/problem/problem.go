@@ -0,0 +1,86 @@
+// Package problem writes the body of refused requests, as RFC 9457
+// application/problem+json or in the legacy {"message": ...} shape
+package problem
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+)
+
+const (
+	ContentType       = "application/problem+json"
+	LegacyContentType = "application/json; charset=utf-8"
+)
+
+// Details is an RFC 9457 problem with the rate limiter's extension members
+type Details struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance,omitempty"`
+
+	Code    string `json:"code,omitempty"`
+	Level   string `json:"level,omitempty"`
+	Service string `json:"service,omitempty"`
+	// Limit and Remaining describe the window that applied, when there is one
+	Limit     int  `json:"limit,omitempty"`
+	Remaining *int `json:"remaining,omitempty"`
+	// RetryAfter (in seconds) and Reset tell when the caller may try again
+	RetryAfter *int   `json:"retry_after,omitempty"`
+	Reset      string `json:"reset,omitempty"`
+}
+
+// Body returns the content type and body of the refusal block, in the format
+// of the caller's service. status is the code the response is written with,
+// which may differ from the block's; instance is the path of the refused request.
+func Body(block v.VerifyOutputDTO, status int, instance string, now time.Time) (string, any) {
+	if block.ErrorFormat == entity.ErrorFormatLegacy {
+		return LegacyContentType, legacy(block)
+	}
+
+	details := Details{
+		Type:     block.ProblemType,
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   block.Message,
+		Instance: instance,
+		Code:     block.Code,
+		Level:    block.Level,
+		Service:  block.Name,
+	}
+	if details.Type == "" {
+		details.Type = "about:blank"
+	}
+	if block.Limit > 0 {
+		remaining := block.Remaining
+		details.Limit = block.Limit
+		details.Remaining = &remaining
+	}
+	if !block.ResetAt.IsZero() {
+		retryAfter := RetryAfter(block.ResetAt, now)
+		details.RetryAfter = &retryAfter
+		details.Reset = block.ResetAt.UTC().Format(time.RFC3339)
+	}
+	return ContentType, details
+}
+
+// RetryAfter is how many whole seconds from now the caller must wait until resetAt
+func RetryAfter(resetAt time.Time, now time.Time) int {
+	return max(int(math.Ceil(resetAt.Sub(now).Seconds())), 0)
+}
+
+func legacy(block v.VerifyOutputDTO) map[string]string {
+	body := map[string]string{"message": block.Message}
+	if block.Level != "" {
+		body["level"] = block.Level
+	}
+	if block.Code != "" {
+		body["code"] = block.Code
+	}
+	return body
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"testing"
 
-	"ratelim/internal/domain/mydomain/usecase"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/domain/mydomain/usecase"
 
 	"github.com/stretchr/testify/assert"
 )
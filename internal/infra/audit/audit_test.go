@@ -0,0 +1,106 @@
+package audit_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/infra/audit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collector keeps the events it is given
+type collector struct {
+	events []v.AuditEvent
+}
+
+func (c *collector) RecordAudit(ctx context.Context, event v.AuditEvent) {
+	c.events = append(c.events, event)
+}
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func TestSampler_DropsFloodsAndReportsSuppressed(t *testing.T) {
+	// Arrange
+	next := &collector{}
+	clk := &clock{t: time.Unix(1000, 0)}
+	sampler := audit.NewSampler(next, 2, clk.now)
+	limited := v.AuditEvent{Service: "service-a", Status: 429}
+
+	// Act
+	for range 5 {
+		sampler.RecordAudit(context.Background(), limited)
+	}
+	sampler.RecordAudit(context.Background(), v.AuditEvent{Service: "service-a", Status: 403})
+	clk.t = clk.t.Add(time.Second)
+	sampler.RecordAudit(context.Background(), limited)
+
+	// Assert
+	require.Len(t, next.events, 4)
+	assert.Equal(t, 0, next.events[1].Suppressed)
+	assert.Equal(t, 403, next.events[2].Status)
+	assert.Equal(t, 3, next.events[3].Suppressed)
+}
+
+func TestSampler_SharesTheBudgetAcrossServices(t *testing.T) {
+	// Arrange
+	next := &collector{}
+	clk := &clock{t: time.Unix(1000, 0)}
+	sampler := audit.NewSampler(next, 2, clk.now)
+
+	// Act
+	// Unknown callers each get a service of their own
+	for i := range 10 {
+		sampler.RecordAudit(context.Background(), v.AuditEvent{Service: fmt.Sprintf("service-%04d", i), Status: 429, Level: "key"})
+	}
+	sampler.RecordAudit(context.Background(), v.AuditEvent{Service: "service-a", Status: 429, Level: "global"})
+	clk.t = clk.t.Add(time.Second)
+	sampler.RecordAudit(context.Background(), v.AuditEvent{Service: "service-b", Status: 429, Level: "key"})
+
+	// Assert
+	require.Len(t, next.events, 4)
+	assert.Equal(t, "global", next.events[2].Level)
+	assert.Equal(t, "service-b", next.events[3].Service)
+	assert.Equal(t, 8, next.events[3].Suppressed)
+}
+
+func TestRotatingFile_RotatesAndKeepsBackups(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "audit.log")
+	file, err := audit.NewRotatingFile(path, 120, 2)
+	require.NoError(t, err)
+	writer := audit.NewJSONWriter(file, nil)
+
+	// Act
+	for _, service := range []string{"a", "b", "c", "d"} {
+		writer.RecordAudit(context.Background(), v.AuditEvent{Service: service, Status: 429, Message: "limite"})
+	}
+	require.NoError(t, file.Close())
+
+	// Assert
+	services := func(name string) []string {
+		f, err := os.Open(name)
+		require.NoError(t, err)
+		defer f.Close()
+		var found []string
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			found = append(found, strings.Split(strings.Split(scanner.Text(), `"service":"`)[1], `"`)[0])
+		}
+		return found
+	}
+	assert.Equal(t, []string{"d"}, services(path))
+	assert.Equal(t, []string{"c"}, services(path+".1"))
+	assert.Equal(t, []string{"b"}, services(path+".2"))
+	assert.NoFileExists(t, path+".3")
+}
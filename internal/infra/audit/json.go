@@ -0,0 +1,35 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/infra/logging"
+)
+
+// JSONWriter writes every audit event as one JSON line to w, e.g. os.Stdout or
+// a RotatingFile
+type JSONWriter struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	logger *slog.Logger
+}
+
+// NewJSONWriter builds a JSONWriter on w. Write errors go to logger, or to
+// slog.Default() when it is nil.
+func NewJSONWriter(w io.Writer, logger *slog.Logger) *JSONWriter {
+	return &JSONWriter{enc: json.NewEncoder(w), logger: logging.OrDefault(logger)}
+}
+
+func (j *JSONWriter) RecordAudit(ctx context.Context, event v.AuditEvent) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.enc.Encode(event); err != nil {
+		j.logger.ErrorContext(ctx, "falha ao gravar evento de auditoria", "error", err)
+	}
+}
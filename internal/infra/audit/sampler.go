@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+)
+
+// Sampler forwards at most perSecond events per status and level each second,
+// so a flood of refusals does not overwhelm the sink. Streams are not keyed by
+// service, as unknown callers each get a service of their own. The next event
+// forwarded after a drop tells how many were suppressed.
+type Sampler struct {
+	next      v.AuditRecorder
+	perSecond int
+	now       func() time.Time
+
+	mu      sync.Mutex
+	second  int64
+	streams map[string]*stream
+}
+
+type stream struct {
+	second     int64
+	sent       int
+	suppressed int
+}
+
+func NewSampler(next v.AuditRecorder, perSecond int, now func() time.Time) *Sampler {
+	return &Sampler{
+		next:      next,
+		perSecond: perSecond,
+		now:       now,
+		streams:   make(map[string]*stream),
+	}
+}
+
+func (s *Sampler) RecordAudit(ctx context.Context, event v.AuditEvent) {
+	second := s.now().Unix()
+	id := fmt.Sprintf("%d:%s", event.Status, event.Level)
+
+	s.mu.Lock()
+	if second != s.second {
+		s.second = second
+		s.evict(second)
+	}
+	st, ok := s.streams[id]
+	if !ok {
+		st = &stream{second: second}
+		s.streams[id] = st
+	}
+	if st.second != second {
+		st.second = second
+		st.sent = 0
+	}
+	if st.sent >= s.perSecond {
+		st.suppressed++
+		s.mu.Unlock()
+		return
+	}
+	st.sent++
+	event.Suppressed = st.suppressed
+	st.suppressed = 0
+	s.mu.Unlock()
+
+	s.next.RecordAudit(ctx, event)
+}
+
+// evict drops the streams idle since a previous second; those with suppressed
+// events are kept so the count is reported with their next event
+func (s *Sampler) evict(second int64) {
+	for id, st := range s.streams {
+		if st.second < second && st.suppressed == 0 {
+			delete(s.streams, id)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// auditBatchSize caps how many events are sent in one pipeline
+const auditBatchSize = 100
+
+// AuditStream appends audit events to a Redis stream, each as a JSON "event"
+// field, trimming it to about maxLen entries (0 = unbounded). Events are queued
+// in a buffer of bufferSize and sent in batches by a background goroutine, so
+// the request path never waits on Redis; events arriving while the buffer is
+// full are dropped and counted. Close sends whatever is still queued.
+type AuditStream struct {
+	client *redis.Client
+	logger *slog.Logger
+	stream string
+	maxLen int64
+
+	mu      sync.RWMutex
+	closed  bool
+	events  chan []byte
+	done    chan struct{}
+	dropped atomic.Int64
+}
+
+func (r *RedisStore) AuditStream(stream string, maxLen int64, bufferSize int) *AuditStream {
+	a := &AuditStream{
+		client: r.client,
+		logger: r.logger,
+		stream: stream,
+		maxLen: maxLen,
+		events: make(chan []byte, bufferSize),
+		done:   make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+func (a *AuditStream) RecordAudit(ctx context.Context, event v.AuditEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		a.logger.ErrorContext(ctx, "falha ao serializar evento de auditoria", "error", err)
+		return
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		a.dropped.Add(1)
+		return
+	}
+
+	select {
+	case a.events <- payload:
+	default:
+		// Buffer cheio: descarta em vez de atrasar a requisição
+		a.dropped.Add(1)
+	}
+}
+
+// Dropped returns how many events were discarded because the buffer was full
+// or the stream was already closed
+func (a *AuditStream) Dropped() int64 {
+	return a.dropped.Load()
+}
+
+// Close stops accepting events and waits until the queued ones are sent
+func (a *AuditStream) Close() error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.events)
+	}
+	a.mu.Unlock()
+
+	<-a.done
+	return nil
+}
+
+func (a *AuditStream) run() {
+	defer close(a.done)
+
+	var reported int64
+	for payload := range a.events {
+		batch := [][]byte{payload}
+		// Junta o que já estiver na fila num único pipeline
+	drain:
+		for len(batch) < auditBatchSize {
+			select {
+			case next, ok := <-a.events:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+		a.send(batch)
+
+		if dropped := a.dropped.Load(); dropped > reported {
+			a.logger.Warn("eventos de auditoria descartados", "stream", a.stream, "dropped", dropped-reported)
+			reported = dropped
+		}
+	}
+}
+
+func (a *AuditStream) send(batch [][]byte) {
+	pipe := a.client.Pipeline()
+	for _, payload := range batch {
+		pipe.XAdd(context.Background(), &redis.XAddArgs{
+			Stream: a.stream,
+			MaxLen: a.maxLen,
+			Approx: a.maxLen > 0,
+			Values: map[string]interface{}{"event": payload},
+		})
+	}
+	if _, err := pipe.Exec(context.Background()); err != nil {
+		a.logger.Error("falha ao gravar eventos de auditoria", "stream", a.stream, "events", len(batch), "error", err)
+	}
+}
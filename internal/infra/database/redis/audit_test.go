@@ -0,0 +1,53 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditStream_SendsQueuedEventsOnClose(t *testing.T) {
+	// Arrange
+	store := newTestStore(t)
+	stream := store.AuditStream("audit_test", 0, 10)
+
+	// Act
+	for _, service := range []string{"service-a", "service-b"} {
+		stream.RecordAudit(context.Background(), v.AuditEvent{Service: service, Status: 429})
+	}
+	require.NoError(t, stream.Close())
+	stream.RecordAudit(context.Background(), v.AuditEvent{Service: "service-c", Status: 429})
+	entries, err := store.client.XRange(context.Background(), "audit_test", "-", "+").Result()
+	require.NoError(t, err)
+
+	// Assert
+	require.Len(t, entries, 2)
+	var first v.AuditEvent
+	require.NoError(t, json.Unmarshal([]byte(entries[0].Values["event"].(string)), &first))
+	assert.Equal(t, "service-a", first.Service)
+	// Events recorded after Close are dropped instead of panicking
+	assert.Equal(t, int64(1), stream.Dropped())
+}
+
+func TestAuditStream_DropsEventsWhenTheBufferIsFull(t *testing.T) {
+	// Arrange
+	store := newTestStore(t)
+	stream := store.AuditStream("audit_test", 0, 1)
+
+	// Act
+	// Sem espaço no buffer, parte dos eventos é descartada em vez de bloquear
+	for i := 0; i < 1000; i++ {
+		stream.RecordAudit(context.Background(), v.AuditEvent{Service: "service-a", Status: 429})
+	}
+	require.NoError(t, stream.Close())
+	sent := store.client.XLen(context.Background(), "audit_test").Val()
+
+	// Assert
+	assert.Positive(t, sent)
+	assert.Equal(t, int64(1000), sent+stream.Dropped())
+}
@@ -0,0 +1,84 @@
+package redis
+
+import (
+	"sync"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+)
+
+// ConfigCache keeps resolved service configs in memory so the hot path does not
+// need an HGET per request. Entries expire after ttl as a safety net in case an
+// invalidation message is lost.
+type ConfigCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[string]cachedConfig
+	now     func() time.Time
+	// generation changes on every invalidation, so that a config read before
+	// one is not cached after it
+	generation uint64
+}
+
+type cachedConfig struct {
+	cfg       entity.ServiceConfig
+	expiresAt time.Time
+}
+
+func NewConfigCache(ttl time.Duration) *ConfigCache {
+	return &ConfigCache{
+		ttl:     ttl,
+		entries: make(map[string]cachedConfig),
+		now:     time.Now,
+	}
+}
+
+func (c *ConfigCache) Get(key string) (entity.ServiceConfig, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok {
+		return entity.ServiceConfig{}, false
+	}
+	if !c.now().Before(entry.expiresAt) {
+		c.mu.Lock()
+		delete(c.entries, key)
+		c.mu.Unlock()
+		return entity.ServiceConfig{}, false
+	}
+	return entry.cfg, true
+}
+
+func (c *ConfigCache) Set(key string, cfg entity.ServiceConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cachedConfig{cfg: cfg, expiresAt: c.now().Add(c.ttl)}
+}
+
+// Generation returns the current generation, to be passed to SetIfCurrent
+// once the config read after this call is known
+func (c *ConfigCache) Generation() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.generation
+}
+
+// SetIfCurrent caches cfg unless an invalidation happened since generation was
+// taken, in which case cfg may be stale and is dropped
+func (c *ConfigCache) SetIfCurrent(key string, cfg entity.ServiceConfig, generation uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		return false
+	}
+	c.entries[key] = cachedConfig{cfg: cfg, expiresAt: c.now().Add(c.ttl)}
+	return true
+}
+
+func (c *ConfigCache) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	c.generation++
+}
@@ -0,0 +1,65 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigCache_ReturnsEntryUntilTTLExpires(t *testing.T) {
+	// Arrange
+	now := time.Unix(1000, 0)
+	cache := NewConfigCache(10 * time.Second)
+	cache.now = func() time.Time { return now }
+	cache.Set("abcd1234", entity.ServiceConfig{Name: "service-a", Key: "abcd1234"})
+
+	// Act
+	cfg, hit := cache.Get("abcd1234")
+	now = now.Add(10 * time.Second)
+	_, hitAfterTTL := cache.Get("abcd1234")
+
+	// Assert
+	assert.True(t, hit)
+	assert.Equal(t, "service-a", cfg.Name)
+	assert.False(t, hitAfterTTL)
+}
+
+func TestConfigCache_Invalidate(t *testing.T) {
+	// Arrange
+	cache := NewConfigCache(time.Minute)
+	cache.Set("a", entity.ServiceConfig{Name: "service-a"})
+	cache.Set("b", entity.ServiceConfig{Name: "service-b"})
+
+	// Act
+	cache.Invalidate("a")
+	_, hitA := cache.Get("a")
+	_, hitB := cache.Get("b")
+
+	// Assert
+	assert.False(t, hitA)
+	assert.True(t, hitB)
+}
+
+func TestConfigCache_SetIfCurrentDropsConfigsReadBeforeAnInvalidation(t *testing.T) {
+	// Arrange
+	cache := NewConfigCache(time.Minute)
+	stale := cache.Generation()
+	cache.Invalidate("a")
+	current := cache.Generation()
+
+	// Act
+	staleSet := cache.SetIfCurrent("a", entity.ServiceConfig{Name: "old-a"}, stale)
+	_, staleHit := cache.Get("a")
+	currentSet := cache.SetIfCurrent("a", entity.ServiceConfig{Name: "new-a"}, current)
+	cfg, currentHit := cache.Get("a")
+
+	// Assert
+	assert.False(t, staleSet)
+	assert.False(t, staleHit)
+	assert.True(t, currentSet)
+	assert.True(t, currentHit)
+	assert.Equal(t, "new-a", cfg.Name)
+}
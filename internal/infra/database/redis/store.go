@@ -4,10 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"math/rand"
-	"ratelim/internal/api/web/middleware/ratelimiter/entity"
+	"strconv"
 	"time"
 
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/repository"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/infra/logging"
+
 	"github.com/redis/go-redis/v9"
 )
 
@@ -20,17 +25,64 @@ func RandomString(length int) string {
 	return string(b)
 }
 
+const (
+	configHashKey         = "rate_limit_config"
+	configInvalidateTopic = "rate_limit_config:invalidate"
+)
+
 type RedisStore struct {
 	client *redis.Client
+	cache  *ConfigCache
+	pubsub *redis.PubSub
+	logger *slog.Logger
 }
 
-func NewRedisStore(addr, password string, db int) *RedisStore {
+// NewRedisStore connects to Redis. When configCacheTTL is positive, resolved
+// service configs are cached in memory and invalidated across instances through
+// the configInvalidateTopic pub/sub channel. A nil logger logs to slog.Default().
+func NewRedisStore(addr, password string, db int, configCacheTTL time.Duration, logger *slog.Logger) *RedisStore {
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     addr,
 		Password: password,
 		DB:       db,
 	})
-	return &RedisStore{client: rdb}
+	store := &RedisStore{client: rdb, logger: logging.OrDefault(logger)}
+
+	if configCacheTTL > 0 {
+		store.cache = NewConfigCache(configCacheTTL)
+		store.pubsub = rdb.Subscribe(context.Background(), configInvalidateTopic)
+		go store.listenInvalidations()
+	}
+
+	return store
+}
+
+// listenInvalidations drops cached configs as other instances publish changes.
+// The channel is closed by Close, which ends the loop.
+func (r *RedisStore) listenInvalidations() {
+	for msg := range r.pubsub.Channel() {
+		r.cache.Invalidate(msg.Payload)
+	}
+}
+
+// publishInvalidation must be called after every write or delete of a service config
+func (r *RedisStore) publishInvalidation(ctx context.Context, key string) {
+	if r.cache == nil {
+		return
+	}
+	r.cache.Invalidate(key)
+	if err := r.client.Publish(ctx, configInvalidateTopic, key).Err(); err != nil {
+		r.logger.WarnContext(ctx, "falha ao publicar invalidação de config",
+			"key_fingerprint", entity.KeyFingerprint(key), "error", err)
+	}
+}
+
+// cacheGeneration returns the generation of the config cache, if there is one
+func (r *RedisStore) cacheGeneration() uint64 {
+	if r.cache == nil {
+		return 0
+	}
+	return r.cache.Generation()
 }
 
 func (r *RedisStore) SetServiceConfig(cfg entity.ServiceConfig) error {
@@ -42,7 +94,12 @@ func (r *RedisStore) SetServiceConfig(cfg entity.ServiceConfig) error {
 	}
 
 	// Armazena no Redis Hash "rate_limit_config" com campo = cfg.Key
-	return r.client.HSet(ctx, "rate_limit_config", cfg.Key, data).Err()
+	if err := r.client.HSet(ctx, configHashKey, cfg.Key, data).Err(); err != nil {
+		return err
+	}
+
+	r.publishInvalidation(ctx, cfg.Key)
+	return nil
 }
 
 func (r *RedisStore) GetServiceRateLimit(key string) (entity.ServiceConfig, error) {
@@ -50,17 +107,31 @@ func (r *RedisStore) GetServiceRateLimit(key string) (entity.ServiceConfig, erro
 
 	var cfg entity.ServiceConfig
 
-	// 1. Tenta buscar config da chave normalmente
-	val, err := r.client.HGet(ctx, "rate_limit_config", key).Result()
+	// 0. Usa a config em cache, se houver
+	if r.cache != nil {
+		if cached, ok := r.cache.Get(key); ok {
+			return cached, nil
+		}
+	}
+
+	// 1. Tenta buscar config da chave normalmente; uma invalidação recebida
+	// durante a leitura impede que a config lida vá para o cache
+	generation := r.cacheGeneration()
+	val, err := r.client.HGet(ctx, configHashKey, key).Result()
 	if err == nil {
-		err = json.Unmarshal([]byte(val), &cfg)
-		return cfg, err
+		if err = json.Unmarshal([]byte(val), &cfg); err != nil {
+			return cfg, err
+		}
+		if r.cache != nil {
+			r.cache.SetIfCurrent(key, cfg, generation)
+		}
+		return cfg, nil
 	}
 
 	// 2. Se não encontrou a chave, aplica comportamento com base no default
 	if err == redis.Nil {
 		// 2.1 Busca config do default
-		defaultVal, derr := r.client.HGet(ctx, "rate_limit_config", "default").Result()
+		defaultVal, derr := r.client.HGet(ctx, configHashKey, entity.DefaultServiceName).Result()
 		if derr != nil {
 			return cfg, fmt.Errorf("configuração default não encontrada: %v", derr)
 		}
@@ -75,11 +146,16 @@ func (r *RedisStore) GetServiceRateLimit(key string) (entity.ServiceConfig, erro
 		newCfg.Key = key
 		newCfg.Name = fmt.Sprintf("service-%s", RandomString(12))
 		newCfg.Valid = true
+		newCfg.Unregistered = true
 
 		// 2.3 Salva essa nova configuração com base na default
 		if setErr := r.SetServiceConfig(newCfg); setErr != nil {
 			// Mesmo que falhe ao salvar, ainda retornamos a config aplicada
-			fmt.Printf("aviso: falha ao salvar config para nova chave %s: %v\n", key, setErr)
+			r.logger.Warn("falha ao salvar config para nova chave",
+				"key_fingerprint", entity.KeyFingerprint(key), "error", setErr)
+		} else if r.cache != nil {
+			// A própria escrita invalidou a chave; a geração é lida depois dela
+			r.cache.SetIfCurrent(key, newCfg, r.cache.Generation())
 		}
 
 		return newCfg, nil
@@ -89,17 +165,281 @@ func (r *RedisStore) GetServiceRateLimit(key string) (entity.ServiceConfig, erro
 	return cfg, err
 }
 
-func (r *RedisStore) IncrementRequestCount(key string, windowKey string) (int, error) {
+func (r *RedisStore) LookupServiceConfig(key string) (entity.ServiceConfig, bool, error) {
+	ctx := context.Background()
+
+	// Chaves desconhecidas já vistas ficam em cache como cópias da config default
+	if r.cache != nil {
+		if cached, ok := r.cache.Get(key); ok {
+			return cached, !cached.Unregistered, nil
+		}
+	}
+
+	var cfg entity.ServiceConfig
+	generation := r.cacheGeneration()
+	val, err := r.client.HGet(ctx, configHashKey, key).Result()
+	if err == redis.Nil {
+		return cfg, false, nil
+	}
+	if err != nil {
+		return cfg, false, err
+	}
+	if err := json.Unmarshal([]byte(val), &cfg); err != nil {
+		return cfg, false, err
+	}
+	if r.cache != nil {
+		r.cache.SetIfCurrent(key, cfg, generation)
+	}
+	return cfg, !cfg.Unregistered, nil
+}
+
+func (r *RedisStore) IncrementRequestCount(key string, windowKey string, amount int) (int, error) {
 	ctx := context.Background()
 	fullKey := fmt.Sprintf("rate_limit_counter:%s:%s", key, windowKey)
 
-	count, err := r.client.Incr(ctx, fullKey).Result()
+	count, err := r.client.IncrBy(ctx, fullKey, int64(amount)).Result()
 	return int(count), err
 }
 
+// incrementCountersScript adds ARGV[1] to every key and sets the TTL (ARGV[i+1],
+// in milliseconds) of the keys it creates
+var incrementCountersScript = redis.NewScript(`
+local amount = tonumber(ARGV[1])
+local totals = {}
+for i, key in ipairs(KEYS) do
+	local count = redis.call("INCRBY", key, amount)
+	if count == amount then
+		redis.call("PEXPIRE", key, ARGV[i + 1])
+	end
+	totals[i] = count
+end
+return totals
+`)
+
+func (r *RedisStore) IncrementRequestCounts(counters []repository.CounterRef, amount int) ([]int, error) {
+	ctx := context.Background()
+
+	keys := make([]string, len(counters))
+	args := []interface{}{amount}
+	for i, c := range counters {
+		keys[i] = fmt.Sprintf("rate_limit_counter:%s:%s", c.Key, c.WindowKey)
+		args = append(args, c.TTL.Milliseconds())
+	}
+
+	totals, err := incrementCountersScript.Run(ctx, r.client, keys, args...).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	counts := make([]int, len(totals))
+	for i, t := range totals {
+		counts[i] = int(t)
+	}
+	return counts, nil
+}
+
+func (r *RedisStore) GetRequestCounts(counters []repository.CounterRef) ([]int, error) {
+	ctx := context.Background()
+
+	if len(counters) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(counters))
+	for i, c := range counters {
+		keys[i] = fmt.Sprintf("rate_limit_counter:%s:%s", c.Key, c.WindowKey)
+	}
+
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	counts := make([]int, len(values))
+	for i, value := range values {
+		// Contadores ainda não criados valem zero
+		if value == nil {
+			continue
+		}
+		s, _ := value.(string)
+		if counts[i], err = strconv.Atoi(s); err != nil {
+			return nil, err
+		}
+	}
+	return counts, nil
+}
+
+// refundScript decrements an existing counter without letting it go negative
+var refundScript = redis.NewScript(`
+local current = tonumber(redis.call("GET", KEYS[1]))
+if not current then
+	return 0
+end
+local refund = math.min(current, tonumber(ARGV[1]))
+return redis.call("DECRBY", KEYS[1], refund)
+`)
+
+func (r *RedisStore) RefundRequestCount(key string, windowKey string, amount int) error {
+	ctx := context.Background()
+	fullKey := fmt.Sprintf("rate_limit_counter:%s:%s", key, windowKey)
+
+	return refundScript.Run(ctx, r.client, []string{fullKey}, amount).Err()
+}
+
+func (r *RedisStore) GetByteCount(key string, windowKey string) (int64, error) {
+	ctx := context.Background()
+	fullKey := fmt.Sprintf("rate_limit_bytes:%s:%s", key, windowKey)
+
+	count, err := r.client.Get(ctx, fullKey).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
+}
+
+func (r *RedisStore) AdjustByteCount(key string, windowKey string, delta int64, ttl time.Duration) (int64, error) {
+	ctx := context.Background()
+	fullKey := fmt.Sprintf("rate_limit_bytes:%s:%s", key, windowKey)
+
+	pipe := r.client.TxPipeline()
+	incr := pipe.IncrBy(ctx, fullKey, delta)
+	pipe.Expire(ctx, fullKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
 func (r *RedisStore) SetExpiration(key string, windowKey string, ttl time.Duration) error {
 	ctx := context.Background()
 	fullKey := fmt.Sprintf("rate_limit_counter:%s:%s", key, windowKey)
 
 	return r.client.Expire(ctx, fullKey, ttl).Err()
 }
+
+// acquireSlotScript drops expired leases and adds a new one if there is room.
+// Leases live in a sorted set scored by their expiration in milliseconds.
+var acquireSlotScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now)
+if redis.call("ZCARD", KEYS[1]) >= tonumber(ARGV[3]) then
+	return 0
+end
+redis.call("ZADD", KEYS[1], now + tonumber(ARGV[4]), ARGV[2])
+redis.call("PEXPIRE", KEYS[1], ARGV[4])
+return 1
+`)
+
+// renewSlotScript only extends leases that still exist
+var renewSlotScript = redis.NewScript(`
+if redis.call("ZSCORE", KEYS[1], ARGV[2]) then
+	redis.call("ZADD", KEYS[1], tonumber(ARGV[1]) + tonumber(ARGV[3]), ARGV[2])
+	redis.call("PEXPIRE", KEYS[1], ARGV[3])
+end
+return 1
+`)
+
+// shapingScript implements GCRA: the key holds the theoretical arrival time
+// (TAT) of the next request, in microseconds so that high rates keep precision
+var shapingScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local cost = tonumber(ARGV[2])
+local interval = tonumber(ARGV[3])
+local burst = tonumber(ARGV[4])
+local max_delay = tonumber(ARGV[5])
+local max_queue = tonumber(ARGV[6])
+
+local tat = tonumber(redis.call("GET", KEYS[1])) or now
+if tat < now then
+	tat = now
+end
+
+local wait = tat - now - (burst - 1) * interval
+if wait < 0 then
+	wait = 0
+end
+if wait > max_delay then
+	return {0, wait}
+end
+if max_queue > 0 and wait > 0 and math.ceil(wait / interval) > max_queue then
+	return {0, wait}
+end
+
+local new_tat = tat + cost * interval
+redis.call("SET", KEYS[1], new_tat, "PX", math.ceil((new_tat - now) / 1000) + 1000)
+return {1, wait}
+`)
+
+func (r *RedisStore) ReserveShapingSlot(key string, cost int, interval time.Duration, burst int, maxDelay time.Duration, maxQueue int) (time.Duration, bool, error) {
+	ctx := context.Background()
+	fullKey := fmt.Sprintf("rate_limit_shaping:%s", key)
+
+	res, err := shapingScript.Run(ctx, r.client, []string{fullKey},
+		time.Now().UnixMicro(), cost, interval.Microseconds(), burst, maxDelay.Microseconds(), maxQueue).Int64Slice()
+	if err != nil {
+		return 0, false, err
+	}
+	return time.Duration(res[1]) * time.Microsecond, res[0] == 1, nil
+}
+
+// releaseShapingScript moves the TAT back by the cost of a request that gave up,
+// never before now
+var releaseShapingScript = redis.NewScript(`
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if not tat then
+	return 0
+end
+local now = tonumber(ARGV[1])
+local new_tat = tat - tonumber(ARGV[2]) * tonumber(ARGV[3])
+if new_tat <= now then
+	redis.call("DEL", KEYS[1])
+	return 0
+end
+redis.call("SET", KEYS[1], new_tat, "PX", math.ceil((new_tat - now) / 1000) + 1000)
+return 0
+`)
+
+func (r *RedisStore) ReleaseShapingSlot(key string, cost int, interval time.Duration) error {
+	ctx := context.Background()
+	fullKey := fmt.Sprintf("rate_limit_shaping:%s", key)
+
+	return releaseShapingScript.Run(ctx, r.client, []string{fullKey},
+		time.Now().UnixMicro(), cost, interval.Microseconds()).Err()
+}
+
+func (r *RedisStore) AcquireSlot(key string, leaseID string, max int, ttl time.Duration) (bool, error) {
+	ctx := context.Background()
+	fullKey := fmt.Sprintf("rate_limit_inflight:%s", key)
+
+	acquired, err := acquireSlotScript.Run(ctx, r.client, []string{fullKey},
+		time.Now().UnixMilli(), leaseID, max, ttl.Milliseconds()).Int()
+	return acquired == 1, err
+}
+
+func (r *RedisStore) RenewSlot(key string, leaseID string, ttl time.Duration) error {
+	ctx := context.Background()
+	fullKey := fmt.Sprintf("rate_limit_inflight:%s", key)
+
+	return renewSlotScript.Run(ctx, r.client, []string{fullKey},
+		time.Now().UnixMilli(), leaseID, ttl.Milliseconds()).Err()
+}
+
+func (r *RedisStore) ReleaseSlot(key string, leaseID string) error {
+	ctx := context.Background()
+	fullKey := fmt.Sprintf("rate_limit_inflight:%s", key)
+
+	return r.client.ZRem(ctx, fullKey, leaseID).Err()
+}
+
+func (r *RedisStore) CountSlots(key string) (int, error) {
+	ctx := context.Background()
+	fullKey := fmt.Sprintf("rate_limit_inflight:%s", key)
+
+	// Leases expirados ainda no sorted set não contam
+	count, err := r.client.ZCount(ctx, fullKey, fmt.Sprintf("(%d", time.Now().UnixMilli()), "+inf").Result()
+	return int(count), err
+}
+
+func (r *RedisStore) Close() error {
+	if r.pubsub != nil {
+		_ = r.pubsub.Close()
+	}
+	return r.client.Close()
+}
@@ -0,0 +1,240 @@
+package redis
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDB keeps these tests apart from the data of cmd/ratelimiter's tests
+const testDB = 1
+
+// newTestStore connects to the Redis of REDIS_ADDR (localhost:6379 by default)
+// and empties its test database, so the Lua scripts run for real
+func newTestStore(t *testing.T) *RedisStore {
+	t.Helper()
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	store := NewRedisStore(addr, "", testDB, 0, nil)
+	require.NoError(t, store.client.FlushDB(context.Background()).Err())
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func TestRedisStore_IncrementRequestCountsChargesEveryWindow(t *testing.T) {
+	// Arrange
+	store := newTestStore(t)
+	counters := []repository.CounterRef{
+		{Key: "bu1k", WindowKey: "100", TTL: time.Second},
+		{Key: "group:acme", WindowKey: "100", TTL: time.Minute},
+	}
+
+	// Act
+	first, err := store.IncrementRequestCounts(counters, 40)
+	require.NoError(t, err)
+	second, err := store.IncrementRequestCounts(counters, 60)
+	require.NoError(t, err)
+	read, err := store.GetRequestCounts(append(counters, repository.CounterRef{Key: "unused", WindowKey: "100"}))
+	require.NoError(t, err)
+	groupTTL := store.client.PTTL(context.Background(), "rate_limit_counter:group:acme:100").Val()
+
+	// Assert
+	assert.Equal(t, []int{40, 40}, first)
+	assert.Equal(t, []int{100, 100}, second)
+	assert.Equal(t, []int{100, 100, 0}, read)
+	// The TTL of each window is set when the window is created
+	assert.Greater(t, groupTTL, time.Second)
+	assert.LessOrEqual(t, groupTTL, time.Minute)
+}
+
+func TestRedisStore_RefundRequestCountNeverGoesBelowZero(t *testing.T) {
+	// Arrange
+	store := newTestStore(t)
+	counter := []repository.CounterRef{{Key: "f1aky", WindowKey: "100", TTL: time.Second}}
+	_, err := store.IncrementRequestCounts(counter, 3)
+	require.NoError(t, err)
+
+	// Act
+	require.NoError(t, store.RefundRequestCount("f1aky", "100", 2))
+	afterRefund, err := store.GetRequestCounts(counter)
+	require.NoError(t, err)
+	require.NoError(t, store.RefundRequestCount("f1aky", "100", 5))
+	afterOverRefund, err := store.GetRequestCounts(counter)
+	require.NoError(t, err)
+	require.NoError(t, store.RefundRequestCount("f1aky", "expired", 1))
+	created := store.client.Exists(context.Background(), "rate_limit_counter:f1aky:expired").Val()
+
+	// Assert
+	assert.Equal(t, []int{1}, afterRefund)
+	assert.Equal(t, []int{0}, afterOverRefund)
+	// Refunding an expired window does not bring it back
+	assert.Equal(t, int64(0), created)
+}
+
+func TestRedisStore_AdjustByteCountAddsUpTheMinute(t *testing.T) {
+	// Arrange
+	store := newTestStore(t)
+
+	// Act
+	before, err := store.GetByteCount("d0wn", "100")
+	require.NoError(t, err)
+	_, err = store.AdjustByteCount("d0wn", "100", 6, time.Minute)
+	require.NoError(t, err)
+	total, err := store.AdjustByteCount("d0wn", "100", 4, time.Minute)
+	require.NoError(t, err)
+	after, err := store.GetByteCount("d0wn", "100")
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, int64(0), before)
+	assert.Equal(t, int64(10), total)
+	assert.Equal(t, int64(10), after)
+}
+
+func TestRedisStore_ReserveShapingSlotPacesRequestsAfterTheBurst(t *testing.T) {
+	// Arrange
+	store := newTestStore(t)
+	interval := 20 * time.Millisecond
+	reserve := func() (time.Duration, bool) {
+		wait, ok, err := store.ReserveShapingSlot("b4tch", 1, interval, 2, 30*time.Millisecond, 0)
+		require.NoError(t, err)
+		return wait, ok
+	}
+
+	// Act
+	firstWait, firstOK := reserve()
+	burstWait, burstOK := reserve()
+	queuedWait, queuedOK := reserve()
+	// The next slot is 40ms away, past the maximum delay of 30ms
+	tooLateWait, tooLateOK := reserve()
+
+	// Assert
+	assert.True(t, firstOK)
+	assert.Zero(t, firstWait)
+	assert.True(t, burstOK)
+	assert.Zero(t, burstWait)
+	assert.True(t, queuedOK)
+	assert.InDelta(t, interval, queuedWait, float64(5*time.Millisecond))
+	assert.False(t, tooLateOK)
+	assert.Greater(t, tooLateWait, 30*time.Millisecond)
+}
+
+func TestRedisStore_ReserveShapingSlotLimitsTheQueue(t *testing.T) {
+	// Arrange
+	store := newTestStore(t)
+	reserve := func() bool {
+		_, ok, err := store.ReserveShapingSlot("b4tch", 1, 10*time.Millisecond, 1, time.Second, 2)
+		require.NoError(t, err)
+		return ok
+	}
+
+	// Act
+	// The first request goes through and the next two wait in the queue
+	results := []bool{reserve(), reserve(), reserve(), reserve()}
+
+	// Assert
+	assert.Equal(t, []bool{true, true, true, false}, results)
+}
+
+func TestRedisStore_ReleaseShapingSlotGivesTheSlotToTheNextRequest(t *testing.T) {
+	// Arrange
+	store := newTestStore(t)
+	interval := 20 * time.Millisecond
+	_, _, err := store.ReserveShapingSlot("b4tch", 1, interval, 1, time.Second, 0)
+	require.NoError(t, err)
+	queuedWait, _, err := store.ReserveShapingSlot("b4tch", 1, interval, 1, time.Second, 0)
+	require.NoError(t, err)
+
+	// Act
+	require.NoError(t, store.ReleaseShapingSlot("b4tch", 1, interval))
+	nextWait, ok, err := store.ReserveShapingSlot("b4tch", 1, interval, 1, time.Second, 0)
+	require.NoError(t, err)
+	// Releasing more than what is queued never moves the bucket before now
+	require.NoError(t, store.ReleaseShapingSlot("b4tch", 5, interval))
+	exists := store.client.Exists(context.Background(), "rate_limit_shaping:b4tch").Val()
+
+	// Assert
+	assert.Greater(t, queuedWait, time.Duration(0))
+	assert.True(t, ok)
+	assert.InDelta(t, queuedWait, nextWait, float64(5*time.Millisecond))
+	assert.Equal(t, int64(0), exists)
+}
+
+func TestRedisStore_AcquireSlotHoldsAtMostMaxLeases(t *testing.T) {
+	// Arrange
+	store := newTestStore(t)
+	acquire := func(leaseID string) bool {
+		acquired, err := store.AcquireSlot("rep0rt", leaseID, 2, time.Minute)
+		require.NoError(t, err)
+		return acquired
+	}
+
+	// Act
+	first, second, third := acquire("a"), acquire("b"), acquire("c")
+	require.NoError(t, store.ReleaseSlot("rep0rt", "a"))
+	afterRelease := acquire("c")
+	count, err := store.CountSlots("rep0rt")
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, []bool{true, true, false, true}, []bool{first, second, third, afterRelease})
+	assert.Equal(t, 2, count)
+}
+
+func TestRedisStore_ExpiredLeasesFreeTheirSlot(t *testing.T) {
+	// Arrange
+	store := newTestStore(t)
+	_, err := store.AcquireSlot("rep0rt", "crashed", 1, 20*time.Millisecond)
+	require.NoError(t, err)
+	_, err = store.AcquireSlot("rep0rt", "renewed", 2, 20*time.Millisecond)
+	require.NoError(t, err)
+
+	// Act
+	require.NoError(t, store.RenewSlot("rep0rt", "renewed", time.Minute))
+	// Renewing a lease that was released does not bring it back
+	require.NoError(t, store.RenewSlot("rep0rt", "released", time.Minute))
+	time.Sleep(30 * time.Millisecond)
+	count, err := store.CountSlots("rep0rt")
+	require.NoError(t, err)
+	acquired, err := store.AcquireSlot("rep0rt", "next", 2, time.Minute)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, 1, count)
+	assert.True(t, acquired)
+}
+
+func TestRedisStore_LookupServiceConfigNeverRegistersTheKey(t *testing.T) {
+	// Arrange
+	store := newTestStore(t)
+	require.NoError(t, store.SetServiceConfig(entity.ServiceConfig{Name: entity.DefaultServiceName, Key: entity.DefaultServiceName, Valid: true, AllowedRPS: 5}))
+	require.NoError(t, store.SetServiceConfig(entity.ServiceConfig{Name: "service-a", Key: "abcd1234", Valid: true, AllowedRPS: 10}))
+
+	// Act
+	registered, registeredOK, err := store.LookupServiceConfig("abcd1234")
+	require.NoError(t, err)
+	_, unknownOK, err := store.LookupServiceConfig("mnop1213")
+	require.NoError(t, err)
+	stored := store.client.HExists(context.Background(), configHashKey, "mnop1213").Val()
+	_, err = store.GetServiceRateLimit("mnop1213")
+	require.NoError(t, err)
+	_, copiedOK, err := store.LookupServiceConfig("mnop1213")
+	require.NoError(t, err)
+
+	// Assert
+	assert.True(t, registeredOK)
+	assert.Equal(t, "service-a", registered.Name)
+	assert.False(t, unknownOK)
+	assert.False(t, stored)
+	// Copies of the default config made for unknown keys are not registrations
+	assert.False(t, copiedOK)
+}
@@ -0,0 +1,106 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Cada hora de uso é um hash sem expiração, com os campos
+// "admitted:<serviço>" e "rejected:<serviço>"
+const (
+	usageKeyPrefix  = "rate_limit_usage:"
+	usageHourLayout = "2006-01-02T15"
+	usageAdmitted   = "admitted:"
+	usageRejected   = "rejected:"
+)
+
+func usageKey(hour time.Time) string {
+	return usageKeyPrefix + entity.UsageHour(hour).Format(usageHourLayout)
+}
+
+func (r *RedisStore) AddUsage(records []entity.UsageRecord) error {
+	ctx := context.Background()
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, rec := range records {
+			key := usageKey(rec.Hour)
+			if rec.Admitted > 0 {
+				pipe.HIncrBy(ctx, key, usageAdmitted+rec.Service, rec.Admitted)
+			}
+			if rec.Rejected > 0 {
+				pipe.HIncrBy(ctx, key, usageRejected+rec.Service, rec.Rejected)
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+func (r *RedisStore) GetUsage(from, to time.Time) ([]entity.UsageRecord, error) {
+	ctx := context.Background()
+
+	var hours []time.Time
+	for hour := entity.UsageHour(from); hour.Before(to); hour = hour.Add(time.Hour) {
+		hours = append(hours, hour)
+	}
+
+	pipe := r.client.Pipeline()
+	results := make([]*redis.MapStringStringCmd, len(hours))
+	for i, hour := range hours {
+		results[i] = pipe.HGetAll(ctx, usageKey(hour))
+	}
+	if len(hours) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var records []entity.UsageRecord
+	for i, hour := range hours {
+		byService := map[string]*entity.UsageRecord{}
+		for field, raw := range results[i].Val() {
+			var count int64
+			if _, err := fmt.Sscan(raw, &count); err != nil {
+				return nil, fmt.Errorf("invalid usage count %q in %s: %w", raw, usageKey(hour), err)
+			}
+
+			var service string
+			var admitted bool
+			switch {
+			case strings.HasPrefix(field, usageAdmitted):
+				service, admitted = strings.TrimPrefix(field, usageAdmitted), true
+			case strings.HasPrefix(field, usageRejected):
+				service = strings.TrimPrefix(field, usageRejected)
+			default:
+				continue
+			}
+
+			rec, ok := byService[service]
+			if !ok {
+				rec = &entity.UsageRecord{Hour: hour, Service: service}
+				byService[service] = rec
+			}
+			if admitted {
+				rec.Admitted += count
+			} else {
+				rec.Rejected += count
+			}
+		}
+
+		hourRecords := make([]entity.UsageRecord, 0, len(byService))
+		for _, rec := range byService {
+			hourRecords = append(hourRecords, *rec)
+		}
+		sort.Slice(hourRecords, func(a, b int) bool { return hourRecords[a].Service < hourRecords[b].Service })
+		records = append(records, hourRecords...)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,43 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/infra/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_AddsContextAttributesAndFiltersLevel(t *testing.T) {
+	// Arrange
+	var out bytes.Buffer
+	logger, err := logging.New(&out, "info", "json")
+	require.NoError(t, err)
+	ctx := logging.WithAttrs(context.Background(), slog.String("request_id", "abc"))
+	ctx = logging.WithAttrs(ctx, slog.String("requester", "service-a"))
+
+	// Act
+	logger.DebugContext(ctx, "ignorado")
+	logger.InfoContext(ctx, "requisição recusada", "status", 429)
+
+	// Assert
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, "requisição recusada", record["msg"])
+	assert.Equal(t, "abc", record["request_id"])
+	assert.Equal(t, "service-a", record["requester"])
+	assert.Equal(t, float64(429), record["status"])
+}
+
+func TestNew_RejectsUnknownLevelAndFormat(t *testing.T) {
+	_, levelErr := logging.New(&bytes.Buffer{}, "verbose", "text")
+	_, formatErr := logging.New(&bytes.Buffer{}, "info", "xml")
+
+	assert.Error(t, levelErr)
+	assert.Error(t, formatErr)
+}
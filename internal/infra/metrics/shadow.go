@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"log/slog"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	v "github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/infra/logging"
+)
+
+// ShadowBlocks counts the requests shadow mode limits would have blocked, keyed
+// by "<service>:<limit>" with the service's name in the services file, so keys
+// served by the default service share one entry. It is published with the
+// other expvars on /admin/debug/vars.
+var ShadowBlocks = expvar.NewMap("ratelimit_shadow_blocks_total")
+
+// ShadowRecorder logs every would-be block and counts it in ShadowBlocks
+type ShadowRecorder struct {
+	logger *slog.Logger
+}
+
+// NewShadowRecorder builds a ShadowRecorder. A nil logger logs to slog.Default().
+func NewShadowRecorder(logger *slog.Logger) *ShadowRecorder {
+	return &ShadowRecorder{logger: logging.OrDefault(logger)}
+}
+
+func (r *ShadowRecorder) RecordShadowBlock(ctx context.Context, service string, blocked v.VerifyOutputDTO) {
+	r.logger.InfoContext(ctx, "shadow: requisição seria bloqueada",
+		"service", blocked.Name,
+		"key_fingerprint", entity.KeyFingerprint(blocked.Key),
+		"limit", blocked.LimitName,
+		"limit_value", blocked.Limit,
+		"detail", blocked.Message,
+	)
+	ShadowBlocks.Add(fmt.Sprintf("%s:%s", service, blocked.LimitName), 1)
+}
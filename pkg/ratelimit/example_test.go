@@ -0,0 +1,36 @@
+package ratelimit_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/pkg/ratelimit"
+)
+
+// Other services import the limiter by the module path and wrap their handlers
+// with the net/http middleware
+func ExampleLimiter_Middleware() {
+	limiter := ratelimit.NewLimiter(newMemoryStore())
+	err := limiter.Register(&ratelimit.Config{Services: []*ratelimit.ServiceConfig{
+		{Name: "default", Type: "ip", Address: "any", Key: "default", Valid: true, AllowedRPS: 60},
+		{Name: "billing", Type: "token", Key: "b1ll1ng", Valid: true, AllowedRPS: 60},
+	}})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	hello := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "hello, %s", ratelimit.RequesterFromContext(r.Context()))
+	})
+	handler := limiter.Middleware(nil)(hello)
+
+	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	req.Header.Set(ratelimit.APIKeyHeader, "b1ll1ng")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	fmt.Println(rec.Code, rec.Body.String())
+	// Output: 200 hello, billing
+}
@@ -0,0 +1,15 @@
+// Package ginratelimit adapts a ratelimit.Limiter to gin
+package ginratelimit
+
+import (
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/handlers"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware blocks requests rejected by the limiter and stores the resolved
+// service name under the "Requester" context key
+func Middleware(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return handlers.NewRateLimiter(limiter).Verify()
+}
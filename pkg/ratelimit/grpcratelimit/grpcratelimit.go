@@ -0,0 +1,30 @@
+// Package grpcratelimit adapts a ratelimit.Limiter to gRPC server interceptors
+package grpcratelimit
+
+import (
+	"context"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/grpc/interceptors"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/pkg/ratelimit"
+
+	"google.golang.org/grpc"
+)
+
+// APIKeyMetadata is the metadata key carrying the caller's token
+const APIKeyMetadata = interceptors.APIKeyMetadata
+
+// UnaryServerInterceptor rejects calls refused by the limiter with
+// ResourceExhausted (with RetryInfo), PermissionDenied or Unavailable
+func UnaryServerInterceptor(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return interceptors.NewRateLimiter(limiter).Unary()
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor
+func StreamServerInterceptor(limiter *ratelimit.Limiter) grpc.StreamServerInterceptor {
+	return interceptors.NewRateLimiter(limiter).Stream()
+}
+
+// RequesterFromContext returns the service name resolved for the call
+func RequesterFromContext(ctx context.Context) string {
+	return interceptors.RequesterFromContext(ctx)
+}
@@ -0,0 +1,163 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/problem"
+)
+
+// APIKeyHeader is the header the middleware reads the caller's token from
+const APIKeyHeader = "Api-Key"
+
+type requesterKey struct{}
+
+type costKey struct{}
+
+// requestCost is shared through the context so handlers can report the real cost
+type requestCost struct {
+	mu   sync.Mutex
+	cost int
+}
+
+// SetRequestCost lets a handler running behind Middleware report the real cost
+// of the request. The difference to the cost reserved up front is charged or
+// refunded once the handler returns.
+func SetRequestCost(ctx context.Context, cost int) {
+	if holder, ok := ctx.Value(costKey{}).(*requestCost); ok {
+		holder.mu.Lock()
+		holder.cost = cost
+		holder.mu.Unlock()
+	}
+}
+
+// RequesterFromContext returns the service name resolved for the request, as
+// set by Middleware
+func RequesterFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(requesterKey{}).(string)
+	return name
+}
+
+// Middleware is a net/http middleware usable with the standard library, chi or
+// any router built on http.Handler. clientIP extracts the caller's address; when
+// nil, the host part of r.RemoteAddr is used.
+func (l *Limiter) Middleware(clientIP func(*http.Request) string) func(http.Handler) http.Handler {
+	if clientIP == nil {
+		clientIP = RemoteAddrIP
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			decision := l.Verify(r.Context(), Request{
+				ApiKey:   r.Header.Get(APIKeyHeader),
+				ClientIp: clientIP(r),
+				Method:   r.Method,
+				Path:     r.URL.Path,
+				Language: r.Header.Get("Accept-Language"),
+
+				TrackInFlight: true,
+			})
+			now := time.Now()
+			problem.SetHeaders(w.Header(), decision, now)
+			if decision.Blocked {
+				contentType, body := problem.Body(decision, decision.Status, r.URL.Path, now)
+				w.Header().Set("Content-Type", contentType)
+				w.WriteHeader(decision.Status)
+				_ = json.NewEncoder(w).Encode(body)
+				return
+			}
+
+			if decision.Release != nil {
+				defer decision.Release()
+			}
+
+			holder := &requestCost{}
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			// Settle the reserved quota once the response is known; a panic
+			// counts as a server error
+			started := time.Now()
+			defer func() {
+				holder.mu.Lock()
+				result := Result{Status: recorder.status, Size: recorder.size, Cost: holder.cost, Latency: time.Since(started)}
+				holder.mu.Unlock()
+				if rec := recover(); rec != nil {
+					result.Status = http.StatusInternalServerError
+					_ = l.Settle(r.Context(), decision, result)
+					panic(rec)
+				}
+				_ = l.Settle(r.Context(), decision, result)
+			}()
+
+			ctx := context.WithValue(r.Context(), requesterKey{}, decision.Name)
+			ctx = context.WithValue(ctx, costKey{}, holder)
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+		})
+	}
+}
+
+// RemoteAddrIP returns the host part of r.RemoteAddr
+func RemoteAddrIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// responseRecorder captures the status and body size sent by the handler
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	size        int64
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.size += int64(n)
+	return n, err
+}
+
+// Flush sends buffered data to the client, for streaming handlers
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		r.wroteHeader = true
+		flusher.Flush()
+	}
+}
+
+// Hijack hands the connection over to the handler, e.g. for websockets
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("ratelimit: %T does not support hijacking", r.ResponseWriter)
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil && !r.wroteHeader {
+		// A conexão sequestrada troca de protocolo em vez de responder
+		r.status = http.StatusSwitchingProtocols
+		r.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,308 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/pkg/ratelimit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore is a minimal Store used to exercise the middleware without Redis.
+// The features behind each store method are tested in the verify usecase and
+// the Lua scripts against Redis.
+type memoryStore struct {
+	ratelimit.Store
+	mu       sync.Mutex
+	configs  map[string]ratelimit.ServiceConfig
+	counters map[string]int
+	leases   map[string]map[string]bool
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		configs:  map[string]ratelimit.ServiceConfig{},
+		counters: map[string]int{},
+		leases:   map[string]map[string]bool{},
+	}
+}
+
+func (m *memoryStore) SetServiceConfig(cfg ratelimit.ServiceConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.configs[cfg.Key] = cfg
+	return nil
+}
+func (m *memoryStore) GetServiceRateLimit(key string) (ratelimit.ServiceConfig, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cfg, ok := m.configs[key]; ok {
+		return cfg, nil
+	}
+	if cfg, ok := m.configs["default"]; ok {
+		cfg.Key = key
+		return cfg, nil
+	}
+	return ratelimit.ServiceConfig{}, errors.New("not found")
+}
+func (m *memoryStore) IncrementRequestCount(key string, windowKey string, delta int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[key+":"+windowKey] += delta
+	return m.counters[key+":"+windowKey], nil
+}
+func (m *memoryStore) IncrementRequestCounts(counters []ratelimit.CounterRef, amount int) ([]int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	totals := make([]int, len(counters))
+	for i, c := range counters {
+		m.counters[c.Key+":"+c.WindowKey] += amount
+		totals[i] = m.counters[c.Key+":"+c.WindowKey]
+	}
+	return totals, nil
+}
+func (m *memoryStore) RefundRequestCount(key string, windowKey string, amount int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[key+":"+windowKey] -= min(amount, m.counters[key+":"+windowKey])
+	return nil
+}
+func (m *memoryStore) SetExpiration(key string, windowKey string, ttl time.Duration) error {
+	return nil
+}
+func (m *memoryStore) AcquireSlot(key string, leaseID string, max int, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.leases[key] == nil {
+		m.leases[key] = map[string]bool{}
+	}
+	if len(m.leases[key]) >= max {
+		return false, nil
+	}
+	m.leases[key][leaseID] = true
+	return true, nil
+}
+func (m *memoryStore) RenewSlot(key string, leaseID string, ttl time.Duration) error { return nil }
+func (m *memoryStore) ReleaseSlot(key string, leaseID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.leases[key], leaseID)
+	return nil
+}
+
+// newLimiter registers services, after the default service every config needs
+func newLimiter(t *testing.T, services ...*ratelimit.ServiceConfig) *ratelimit.Limiter {
+	t.Helper()
+	limiter := ratelimit.NewLimiter(newMemoryStore())
+	defaultService := &ratelimit.ServiceConfig{Name: "default", Type: "ip", Address: "any", Key: "default", Valid: true, AllowedRPS: 60}
+	require.NoError(t, limiter.Register(&ratelimit.Config{Services: append([]*ratelimit.ServiceConfig{defaultService}, services...)}))
+	return limiter
+}
+
+// serve sends a GET to path with apiKey through handler
+func serve(handler http.Handler, apiKey, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(ratelimit.APIKeyHeader, apiKey)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddleware_BlocksAfterLimitAndPassesRequester(t *testing.T) {
+	// Arrange
+	limiter := newLimiter(t,
+		&ratelimit.ServiceConfig{Name: "service-a", Type: "token", Key: "abcd1234", Valid: true, AllowedRPS: 2},
+		&ratelimit.ServiceConfig{Name: "service-b", Type: "token", Key: "efgh5678", Valid: false},
+	)
+	var requester string
+	handler := limiter.Middleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requester = ratelimit.RequesterFromContext(r.Context())
+	}))
+
+	// Act
+	var statuses []int
+	for range 5 {
+		statuses = append(statuses, serve(handler, "abcd1234", "/hello").Code)
+	}
+	blocked := serve(handler, "efgh5678", "/hello")
+
+	// Assert
+	assert.Equal(t, "service-a", requester)
+	assert.Equal(t, http.StatusOK, statuses[0])
+	assert.Equal(t, http.StatusTooManyRequests, statuses[4])
+	assert.Equal(t, http.StatusForbidden, blocked.Code)
+}
+
+func TestMiddleware_LimitsConcurrentRequestsAndReleasesOnPanic(t *testing.T) {
+	// Arrange
+	limiter := newLimiter(t,
+		&ratelimit.ServiceConfig{Name: "reports", Type: "token", Key: "rep0rt", Valid: true, AllowedRPS: 100, MaxConcurrent: 1},
+	)
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	handler := limiter.Middleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/panic" {
+			panic("handler failed")
+		}
+		if r.URL.Path == "/slow" {
+			close(started)
+			<-finish
+		}
+	}))
+
+	// Act
+	slowDone := make(chan int)
+	go func() { slowDone <- serve(handler, "rep0rt", "/slow").Code }()
+	<-started
+	whileBusy := serve(handler, "rep0rt", "/fast")
+	close(finish)
+	slowStatus := <-slowDone
+
+	assert.Panics(t, func() { serve(handler, "rep0rt", "/panic") })
+	afterPanic := serve(handler, "rep0rt", "/fast")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, slowStatus)
+	assert.Equal(t, http.StatusTooManyRequests, whileBusy.Code)
+	assert.Equal(t, http.StatusOK, afterPanic.Code)
+}
+
+func TestMiddleware_ChargesTheCostSetByTheHandler(t *testing.T) {
+	// Arrange
+	limiter := newLimiter(t,
+		&ratelimit.ServiceConfig{Name: "bulk", Type: "token", Key: "bu1k", Valid: true, AllowedRPS: 100},
+	)
+	handler := limiter.Middleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/report" {
+			ratelimit.SetRequestCost(r.Context(), 100)
+		}
+	}))
+
+	// Act
+	report := serve(handler, "bu1k", "/report") // 1 up front, 100 after the handler
+	single := serve(handler, "bu1k", "/lookup")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, report.Code)
+	assert.Equal(t, http.StatusTooManyRequests, single.Code)
+}
+
+func TestLimiter_RegisterValidatesConfigsBuiltInCode(t *testing.T) {
+	// Arrange
+	limiter := ratelimit.NewLimiter(newMemoryStore())
+
+	// Act
+	err := limiter.Register(&ratelimit.Config{Services: []*ratelimit.ServiceConfig{
+		{Name: "acme-1", Type: "token", Key: "acme1", Valid: true, AllowedRPS: 10},
+	}})
+
+	// Assert
+	assert.EqualError(t, err, "default service is missing")
+}
+
+func TestLimiter_RegisterWhileServing(t *testing.T) {
+	// Arrange
+	limiter := ratelimit.NewLimiter(newMemoryStore())
+	config := func() *ratelimit.Config {
+		return &ratelimit.Config{
+			Services: []*ratelimit.ServiceConfig{
+				{Name: "default", Type: "ip", Address: "any", Key: "default", Valid: true, AllowedRPS: 60},
+			},
+			Shedding: ratelimit.SheddingConfig{MaxInFlight: 100},
+		}
+	}
+	require.NoError(t, limiter.Register(config()))
+
+	// Act
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				limiter.Verify(context.Background(), ratelimit.Request{ClientIp: "192.0.2.1"})
+			}
+		}()
+	}
+	err := limiter.Register(config())
+	wg.Wait()
+
+	// Assert
+	// Run with -race: reloading the config must not race with Verify
+	assert.NoError(t, err)
+}
+
+func TestMiddleware_DescribesTheWindowInHeaders(t *testing.T) {
+	// Arrange
+	limiter := newLimiter(t, &ratelimit.ServiceConfig{
+		Name: "partner", Type: "token", Key: "partn3r", Valid: true, AllowedRPS: 2,
+		Quotas: []ratelimit.QuotaConfig{{Period: "month", Limit: 100}},
+	})
+	handler := limiter.Middleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// Act
+	admitted := serve(handler, "partn3r", "/hello")
+	serve(handler, "partn3r", "/hello")
+	blocked := serve(handler, "partn3r", "/hello")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, admitted.Code)
+	assert.Equal(t, "2", admitted.Header().Get("X-Ratelimit-Limit"))
+	assert.Equal(t, "1", admitted.Header().Get("X-Ratelimit-Remaining"))
+	assert.NotEmpty(t, admitted.Header().Get("X-Ratelimit-Reset"))
+	assert.Equal(t, "100", admitted.Header().Get("X-Ratelimit-Quota-Month-Limit"))
+	assert.Equal(t, "99", admitted.Header().Get("X-Ratelimit-Quota-Month-Remaining"))
+	assert.Empty(t, admitted.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusTooManyRequests, blocked.Code)
+	assert.Equal(t, "0", blocked.Header().Get("X-Ratelimit-Remaining"))
+	assert.NotEmpty(t, blocked.Header().Get("Retry-After"))
+}
+
+func TestMiddleware_PassesFlushAndHijackThrough(t *testing.T) {
+	// Arrange
+	limiter := newLimiter(t)
+	streaming := limiter.Middleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("chunk"))
+		w.(http.Flusher).Flush()
+	}))
+	upgrade := httptest.NewServer(limiter.Middleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 204 No Content\r\n\r\n")
+		_ = rw.Flush()
+	})))
+	defer upgrade.Close()
+
+	// Act
+	rec := serve(streaming, "", "/stream")
+	resp, err := http.Get(upgrade.URL)
+
+	// Assert
+	assert.True(t, rec.Flushed)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+func TestRemoteAddrIP_StripsPort(t *testing.T) {
+	// Arrange
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:54321"
+
+	// Act
+	ip := ratelimit.RemoteAddrIP(req)
+
+	// Assert
+	assert.Equal(t, "10.0.0.1", ip)
+}
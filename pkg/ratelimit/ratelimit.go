@@ -0,0 +1,138 @@
+// Package ratelimit exposes the rate limiter to other services. It wraps the
+// internal verify usecase behind a Limiter and ships a plain net/http
+// middleware; the gin adapter lives in the ginratelimit subpackage.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/adaptive"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/configs"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/entity"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/repository"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/shedding"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/api/web/middleware/ratelimiter/usecase/verify"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/infra/database/redis"
+	"github.com/LuisGaravaso/goexpert-ratelimiter/internal/infra/metrics"
+)
+
+// Store persists service configs and request counters
+type Store = repository.Store
+
+// GlobalConfig caps the requests admitted per second across all callers
+type GlobalConfig = entity.GlobalConfig
+
+// PriorityService may use the reserved share of the global limit or bypass it
+type PriorityService = entity.PriorityService
+
+// AdaptiveConfig makes a service's limit follow the health of its backend
+type AdaptiveConfig = entity.AdaptiveConfig
+
+// AdaptiveLimit is the current effective limit of an adaptive service
+type AdaptiveLimit = adaptive.Limit
+
+// SheddingConfig drops low priority traffic first when the instance is overloaded
+type SheddingConfig = entity.SheddingConfig
+
+// CounterRef names a window counter updated by Store.IncrementRequestCounts
+// and read by Store.GetRequestCounts
+type CounterRef = repository.CounterRef
+
+// Config is the parsed services file
+type Config = entity.Config
+
+// ServiceConfig is the limit applied to one IP or API key
+type ServiceConfig = entity.ServiceConfig
+
+// Groups maps a group name to the limits shared by its services
+type Groups = entity.Groups
+
+// GroupConfig is the quota shared by every service of a group
+type GroupConfig = entity.GroupConfig
+
+// QuotaConfig caps the requests of a service per day, week or month
+type QuotaConfig = entity.QuotaConfig
+
+// RouteRule sets the cost of requests matching a method and path prefix
+type RouteRule = entity.RouteRule
+
+// Request identifies the caller being checked
+type Request = verify.VerifyInputDTO
+
+// Result describes the response of an admitted request, for Settle
+type Result = verify.SettleInputDTO
+
+// Decision is the outcome of a check. Blocked requests carry the HTTP status
+// and message that should be returned to the caller.
+type Decision = verify.VerifyOutputDTO
+
+// LoadConfig reads and validates a services file (see configs/middleware/services.yaml)
+func LoadConfig(path string) (*Config, error) {
+	return configs.LoadConfig(path)
+}
+
+// NewRedisStore returns a Redis backed Store. A positive configCacheTTL enables
+// the in-process config cache.
+func NewRedisStore(addr, password string, db int, configCacheTTL time.Duration) Store {
+	return redis.NewRedisStore(addr, password, db, configCacheTTL, nil)
+}
+
+// Limiter decides whether a request may proceed
+type Limiter struct {
+	store   Store
+	usecase *verify.VerifyUsecase
+}
+
+func NewLimiter(store Store) *Limiter {
+	l := &Limiter{store: store}
+	// Os controladores seguem o relógio do usecase
+	clock := func() time.Time { return l.usecase.Clock() }
+	// O shedder existe desde o início, sem limites, e Register só troca a sua
+	// config, para não disputar o campo com requisições em andamento
+	shedder := shedding.NewController(entity.SheddingConfig{}, clock)
+	l.usecase = verify.NewVerifyUsecase(store, nil, metrics.NewShadowRecorder(nil), shedder, adaptive.NewController(clock), nil, nil, nil)
+	return l
+}
+
+// Register validates cfg like LoadConfig does and stores every service so the
+// Limiter can resolve them, along with the share of the global limit each one
+// is held to, and enables load shedding when cfg configures it
+func (l *Limiter) Register(cfg *Config) error {
+	if errs := cfg.Validate(); len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if err := cfg.Global.Validate(); err != nil {
+		return err
+	}
+	if err := cfg.Shedding.Validate(); err != nil {
+		return err
+	}
+	if err := cfg.Global.Apply(cfg.Services); err != nil {
+		return err
+	}
+	l.usecase.Shedder.SetConfig(cfg.Shedding)
+	for _, service := range cfg.Services {
+		if err := l.store.SetServiceConfig(*service); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Verify counts the request and reports whether it must be blocked
+func (l *Limiter) Verify(ctx context.Context, req Request) Decision {
+	return l.usecase.Verify(ctx, req)
+}
+
+// Settle commits, refunds or adjusts the quota reserved for an admitted request
+// once its response is known
+func (l *Limiter) Settle(ctx context.Context, decision Decision, result Result) error {
+	return l.usecase.Settle(ctx, decision, result)
+}
+
+// AdaptiveLimits reports the effective limit of every adaptive service seen so far
+func (l *Limiter) AdaptiveLimits() []AdaptiveLimit {
+	return l.usecase.Adaptive.Snapshot()
+}